@@ -0,0 +1,170 @@
+// Command apikey issues, lists and revokes the API keys kept in the key store configured by API_KEY_STORE.
+//
+// It has three subcommands:
+//
+//	create:  Issues a key with the -scopes given (read, write or admin), which expires after -expires unless it is zero, and prints it. The
+//	         key is only shown once, since the store only keeps a hash of it.
+//	list:    Lists the keys along with their scopes and status. Revoked and expired keys are only listed with -all.
+//	revoke:  Revokes the keys with the given ids. The API stops accepting them within a minute.
+//
+// Example usage:
+//
+//	go run ./cmd/apikey create -name "course planner" -scopes read -expires 2160h
+//	go run ./cmd/apikey list -all
+//	go run ./cmd/apikey revoke 3f2a9c0d1e7b
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/apikey"
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+const usage = `usage:
+  apikey create -name <name> -scopes <read,write,admin> [-expires <duration>]
+  apikey list [-all]
+  apikey revoke <id>...`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	store, ok := configs.GetAPIKeyStore()
+	if !ok {
+		log.WriteErrorMsg("API_KEY_STORE isn't set, so there's no key store to manage")
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var err error
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "create":
+		err = create(ctx, store, args)
+	case "list":
+		err = list(ctx, store, args)
+	case "revoke":
+		err = revoke(ctx, store, args)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.WriteError(err)
+		os.Exit(1)
+	}
+}
+
+// create issues a key and prints it.
+func create(ctx context.Context, store apikey.Store, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	name := flags.String("name", "", "Who or what the key is for")
+	scopes := flags.String("scopes", schema.API_KEY_SCOPE_READ, "Comma-separated scopes to grant: read, write or admin")
+	expires := flags.Duration("expires", 0, "How long until the key expires, or 0 for never")
+	flags.Parse(args)
+
+	if *name == "" {
+		return errors.New("-name is required")
+	}
+	if *expires < 0 {
+		return errors.New("-expires can't be negative")
+	}
+
+	var granted []string
+	for _, scope := range strings.Split(*scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			granted = append(granted, scope)
+		}
+	}
+
+	token, key, err := apikey.Generate(*name, granted, *expires)
+	if err != nil {
+		return err
+	}
+	if err = store.Create(ctx, key); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created API key %s for %q with scopes %s", key.Id, key.Name, strings.Join(key.Scopes, ","))
+	if !key.Expires_at.IsZero() {
+		fmt.Printf(", expiring %s", key.Expires_at.Format(time.RFC3339))
+	}
+	fmt.Printf(".\nStore it now, it can't be shown again:\n\n%s\n", token)
+	return nil
+}
+
+// list prints a table of the keys.
+func list(ctx context.Context, store apikey.Store, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	all := flags.Bool("all", false, "Include revoked and expired keys")
+	flags.Parse(args)
+
+	keys, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tNAME\tSCOPES\tCREATED\tEXPIRES\tSTATUS")
+	for _, key := range keys {
+		status := "active"
+		switch {
+		case !key.Revoked_at.IsZero():
+			status = "revoked " + key.Revoked_at.Format(time.DateOnly)
+		case !key.Expires_at.IsZero() && !now.Before(key.Expires_at):
+			status = "expired"
+		}
+		if status != "active" && !*all {
+			continue
+		}
+
+		expires := "never"
+		if !key.Expires_at.IsZero() {
+			expires = key.Expires_at.Format(time.DateOnly)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n", key.Id, key.Name, strings.Join(key.Scopes, ","), key.Created_at.Format(time.DateOnly), expires, status)
+	}
+	return table.Flush()
+}
+
+// revoke revokes every key given, reporting the ids that don't exist.
+func revoke(ctx context.Context, store apikey.Store, ids []string) error {
+	if len(ids) == 0 {
+		return errors.New("no key ids given")
+	}
+
+	var missing []string
+	for _, id := range ids {
+		// Accept whole keys as well as ids, in case that's all that's at hand
+		if keyID, _, err := apikey.Parse(id); err == nil {
+			id = keyID
+		}
+		err := store.Revoke(ctx, id, time.Now())
+		if errors.Is(err, apikey.ErrNotFound) {
+			missing = append(missing, id)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Revoked API key %s.\n", id)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no API key with id %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
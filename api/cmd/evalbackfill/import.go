@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/evaluations"
+	"github.com/UTDNebula/nebula-api/api/common/ues"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// importReports imports every report HTML file in the directory, in batches ordered by file name. Files are matched to their section by the
+// report id in their name. Files holding coursebook's notice that a section has no report are counted as missing without being stored, and
+// files that aren't recognized as a report fail.
+func importReports(opts flags, state *progress) error {
+	entries, err := os.ReadDir(opts.Dir)
+	if err != nil {
+		return err
+	}
+
+	// os.ReadDir sorts by name, so the checkpoint is the last file imported
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".html") && entry.Name() > state.Last {
+			names = append(names, entry.Name())
+		}
+	}
+
+	for start := 0; start < len(names); start += batchSize {
+		batch := names[start:min(start+batchSize, len(names))]
+		for _, name := range batch {
+			if err := importReport(opts, name, state); err != nil {
+				state.fail(name, err)
+			}
+		}
+		if err = state.saveCheckpoint(batch[len(batch)-1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importReport imports a single report file, skipping it if it doesn't match the -term and -prefix filters or its section already has an
+// evaluation.
+func importReport(opts flags, name string, state *progress) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	subjectPrefix, courseNumber, sectionNumber, session, err := ues.ParseReportID(strings.TrimSuffix(name, filepath.Ext(name)))
+	if err != nil {
+		return err
+	}
+	if (opts.Term != "" && !strings.EqualFold(session, opts.Term)) || (opts.Prefix != "" && !strings.EqualFold(subjectPrefix, opts.Prefix)) {
+		state.Skipped++
+		return nil
+	}
+
+	// Courses are listed once per catalog year, so the section may belong to any of them
+	courseIDs, err := configs.GetCollection("courses").Distinct(ctx, "_id", bson.M{"subject_prefix": subjectPrefix, "course_number": courseNumber})
+	if err != nil {
+		return err
+	}
+	var section schema.Section
+	err = configs.GetCollection("sections").FindOne(ctx, bson.M{
+		"course_reference":      bson.M{"$in": courseIDs},
+		"section_number":        sectionNumber,
+		"academic_session.name": session,
+	}).Decode(&section)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("no section matches the report")
+	}
+	if err != nil {
+		return err
+	}
+
+	if !opts.Force {
+		count, err := configs.GetCollection("evaluations").CountDocuments(ctx, bson.M{"_id": section.Id})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			state.Skipped++
+			return nil
+		}
+	}
+
+	file, err := os.Open(filepath.Join(opts.Dir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	eval, err := ues.Parse(file)
+	if errors.Is(err, ues.ErrNoQuestions) {
+		state.Missing++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	eval.Id = section.Id
+	eval.Source = schema.EVALUATION_SOURCE_IMPORT
+
+	if err = evaluations.Store(eval); err != nil {
+		return err
+	}
+	state.Fetched++
+	return nil
+}
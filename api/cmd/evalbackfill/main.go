@@ -0,0 +1,189 @@
+// Command evalbackfill scrapes the evaluations of many sections at once, or imports them from previously saved reports.
+//
+// It runs in one of three modes:
+//
+//	run:     Scrapes the evaluation of every matching section right away, with -workers scrapes at a time and at most one request to
+//	         coursebook every -rate.
+//	enqueue: Queues a scrape job for every matching section, for the API's workers to run at their own pace.
+//	import:  Imports the report HTML files in -dir, each named after its report id (e.g. CS1337.001.23F.html), without network access.
+//
+// Sections are matched by -term and -prefix, and sections with a stored evaluation are skipped unless -force is given. Progress is saved to the
+// -checkpoint file after every batch, so an interrupted backfill picks up where it left off when run again with the same flags. Once finished,
+// the counts of fetched, missing and failed reports are written to the -report file and the checkpoint is removed.
+//
+// Example usage:
+//
+//	go run ./cmd/evalbackfill -mode run -term 23F -prefix CS -workers 4 -rate 3s
+//	go run ./cmd/evalbackfill -mode import -dir ./reports
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/configs"
+)
+
+// How many sections or files are processed between checkpoints.
+const batchSize = 100
+
+// flags holds the command line options.
+type flags struct {
+	Mode       string        `json:"mode"`
+	Term       string        `json:"term,omitempty"`
+	Prefix     string        `json:"prefix,omitempty"`
+	Dir        string        `json:"dir,omitempty"`
+	Force      bool          `json:"force"`
+	Workers    int           `json:"-"`
+	Rate       time.Duration `json:"-"`
+	Checkpoint string        `json:"-"`
+	Report     string        `json:"-"`
+}
+
+// failure is a report that couldn't be fetched or imported.
+type failure struct {
+	Report string `json:"report"`
+	Error  string `json:"error"`
+}
+
+// progress is both the checkpoint of an unfinished backfill and the report of a finished one.
+//
+// Fields:
+//
+//	Options:  The flags the backfill was started with, which a resumed backfill must match.
+//	Last:     The last section id or file name of the last finished batch.
+//	Fetched:  Reports that were scraped or imported.
+//	Missing:  Sections without a report.
+//	Queued:   Sections a scrape job was queued for.
+//	Skipped:  Sections that already had a stored evaluation.
+//	Failed:   Reports that couldn't be fetched or imported, listed in Failures.
+type progress struct {
+	Options     flags     `json:"options"`
+	Started_at  time.Time `json:"started_at"`
+	Finished_at time.Time `json:"finished_at,omitempty"`
+	Last        string    `json:"last,omitempty"`
+	Fetched     int       `json:"fetched"`
+	Missing     int       `json:"missing"`
+	Queued      int       `json:"queued"`
+	Skipped     int       `json:"skipped"`
+	Failed      int       `json:"failed"`
+	Failures    []failure `json:"failures"`
+}
+
+func main() {
+	var opts flags
+	flag.StringVar(&opts.Mode, "mode", "run", "What to do with each section: run, enqueue or import")
+	flag.StringVar(&opts.Term, "term", "", "Only backfill sections of this academic session (e.g. 23F)")
+	flag.StringVar(&opts.Prefix, "prefix", "", "Only backfill sections of courses with this subject prefix (e.g. CS)")
+	flag.StringVar(&opts.Dir, "dir", "", "The directory of report HTML files to import")
+	flag.BoolVar(&opts.Force, "force", false, "Backfill sections that already have a stored evaluation")
+	flag.IntVar(&opts.Workers, "workers", 2, "How many sections to scrape at once")
+	flag.DurationVar(&opts.Rate, "rate", configs.GetEnvScrapeRateLimit(), "The minimum time between requests to coursebook")
+	flag.StringVar(&opts.Checkpoint, "checkpoint", "evalbackfill-checkpoint.json", "Where to save progress")
+	flag.StringVar(&opts.Report, "report", "evalbackfill-report.json", "Where to write the summary report")
+	flag.Parse()
+
+	if err := backfill(opts); err != nil {
+		log.WriteError(err)
+		os.Exit(1)
+	}
+}
+
+// backfill resumes the checkpointed backfill, or starts a new one, and writes its report once it finishes.
+func backfill(opts flags) error {
+	switch opts.Mode {
+	case "run", "enqueue":
+	case "import":
+		if opts.Dir == "" {
+			return errors.New("-dir is required in import mode")
+		}
+	default:
+		return fmt.Errorf("unknown mode %q", opts.Mode)
+	}
+	if opts.Workers < 1 {
+		return errors.New("-workers must be at least 1")
+	}
+
+	state, err := loadCheckpoint(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Mode == "import" {
+		err = importReports(opts, state)
+	} else {
+		err = backfillSections(opts, state)
+	}
+	if err != nil {
+		return err
+	}
+
+	state.Finished_at = time.Now().UTC()
+	if err = writeJSON(opts.Report, state); err != nil {
+		return err
+	}
+	log.Logger.Info().
+		Int("fetched", state.Fetched).
+		Int("missing", state.Missing).
+		Int("queued", state.Queued).
+		Int("skipped", state.Skipped).
+		Int("failed", state.Failed).
+		Str("report", opts.Report).
+		Msg("Backfill finished")
+	return os.Remove(opts.Checkpoint)
+}
+
+// loadCheckpoint returns the progress saved by an interrupted backfill, or new progress if there is none. A checkpoint left by a backfill with
+// different flags is refused rather than resumed or overwritten.
+func loadCheckpoint(opts flags) (*progress, error) {
+	state := &progress{Options: opts, Started_at: time.Now().UTC(), Failures: []failure{}}
+
+	data, err := os.ReadFile(opts.Checkpoint)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved progress
+	if err = json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+	if saved.Options.Mode != opts.Mode || saved.Options.Term != opts.Term || saved.Options.Prefix != opts.Prefix ||
+		saved.Options.Dir != opts.Dir || saved.Options.Force != opts.Force {
+		return nil, fmt.Errorf("%s is the checkpoint of a backfill with different flags; remove it to start over", opts.Checkpoint)
+	}
+	saved.Options = opts
+	log.Logger.Info().Str("last", saved.Last).Msg("Resuming backfill")
+	return &saved, nil
+}
+
+// saveCheckpoint records that everything up to and including last is done.
+func (state *progress) saveCheckpoint(last string) error {
+	state.Last = last
+	return writeJSON(state.Options.Checkpoint, state)
+}
+
+// fail records a report that couldn't be fetched or imported.
+func (state *progress) fail(report string, err error) {
+	state.Failed++
+	state.Failures = append(state.Failures, failure{Report: report, Error: err.Error()})
+}
+
+// writeJSON replaces a file with the JSON of v, writing to a temporary file first so an interruption never leaves it half written.
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
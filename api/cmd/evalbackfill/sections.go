@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/evaluations"
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/common/ues"
+	"github.com/UTDNebula/nebula-api/api/configs"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// How long a single section may take to scrape.
+const scrapeTimeout = 5 * time.Minute
+
+// backfillSections runs or queues a scrape of every matching section, in batches ordered by id.
+func backfillSections(opts flags, state *progress) error {
+	ctx := context.Background()
+	sectionCollection := configs.GetCollection("sections")
+	courseCollection := configs.GetCollection("courses")
+	evaluationCollection := configs.GetCollection("evaluations")
+
+	filter := bson.D{}
+	if opts.Term != "" {
+		filter = append(filter, bson.E{Key: "academic_session.name", Value: opts.Term})
+	}
+	if opts.Prefix != "" {
+		courseIDs, err := courseCollection.Distinct(ctx, "_id", bson.M{"subject_prefix": opts.Prefix})
+		if err != nil {
+			return err
+		}
+		filter = append(filter, bson.E{Key: "course_reference", Value: bson.M{"$in": courseIDs}})
+	}
+
+	var source ues.EvaluationSource
+	if opts.Mode == "run" {
+		source = evaluations.NewSource(opts.Rate)
+	}
+
+	for {
+		// Take the next batch after the checkpoint
+		batchFilter := filter
+		if state.Last != "" {
+			last, err := primitive.ObjectIDFromHex(state.Last)
+			if err != nil {
+				return err
+			}
+			batchFilter = append(bson.D{{Key: "_id", Value: bson.M{"$gt": last}}}, filter...)
+		}
+
+		var sections []struct {
+			Id primitive.ObjectID `bson:"_id"`
+		}
+		cursor, err := sectionCollection.Find(ctx, batchFilter, options.Find().
+			SetProjection(bson.M{"_id": 1}).
+			SetSort(bson.D{{Key: "_id", Value: 1}}).
+			SetLimit(batchSize))
+		if err != nil {
+			return err
+		}
+		if err = cursor.All(ctx, &sections); err != nil {
+			return err
+		}
+		if len(sections) == 0 {
+			return nil
+		}
+
+		var sectionIDs []primitive.ObjectID
+		for _, section := range sections {
+			sectionIDs = append(sectionIDs, section.Id)
+		}
+
+		// Leave sections that already have an evaluation alone
+		if !opts.Force {
+			stored, err := evaluationCollection.Distinct(ctx, "_id", bson.M{"_id": bson.M{"$in": sectionIDs}})
+			if err != nil {
+				return err
+			}
+			storedIDs := make(map[primitive.ObjectID]bool)
+			for _, id := range stored {
+				if id, ok := id.(primitive.ObjectID); ok {
+					storedIDs[id] = true
+				}
+			}
+			var missingIDs []primitive.ObjectID
+			for _, id := range sectionIDs {
+				if storedIDs[id] {
+					state.Skipped++
+				} else {
+					missingIDs = append(missingIDs, id)
+				}
+			}
+			sectionIDs = missingIDs
+		}
+
+		if opts.Mode == "enqueue" {
+			enqueueSections(ctx, sectionIDs, state)
+		} else {
+			scrapeSections(source, opts.Workers, sectionIDs, state)
+		}
+
+		if err = state.saveCheckpoint(sections[len(sections)-1].Id.Hex()); err != nil {
+			return err
+		}
+		log.Logger.Info().
+			Int("fetched", state.Fetched).
+			Int("missing", state.Missing).
+			Int("queued", state.Queued).
+			Int("skipped", state.Skipped).
+			Int("failed", state.Failed).
+			Msg("Backfill progress")
+	}
+}
+
+// enqueueSections queues a scrape job for each section.
+func enqueueSections(ctx context.Context, sectionIDs []primitive.ObjectID, state *progress) {
+	for _, id := range sectionIDs {
+		if _, err := evaluations.Enqueue(ctx, id); err != nil {
+			state.fail(id.Hex(), err)
+			continue
+		}
+		state.Queued++
+	}
+}
+
+// scrapeSections scrapes and stores the evaluation of each section, with up to workers scrapes at a time. The source spaces out the requests.
+func scrapeSections(source ues.EvaluationSource, workers int, sectionIDs []primitive.ObjectID, state *progress) {
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan primitive.ObjectID)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+				eval, err := evaluations.Scrape(ctx, source, id)
+				cancel()
+
+				mutex.Lock()
+				switch {
+				case err != nil:
+					log.WriteError(err)
+					state.fail(id.Hex(), err)
+				case eval.Unavailable:
+					state.Missing++
+				default:
+					state.Fetched++
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for _, id := range sectionIDs {
+		queue <- id
+	}
+	close(queue)
+	wg.Wait()
+}
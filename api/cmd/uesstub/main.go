@@ -0,0 +1,45 @@
+// Command uesstub serves UES reports from local HTML files at coursebook's paths, so evaluation scraping can be run without credentials or
+// network access. Point the API at it with:
+//
+//	COURSEBOOK_URL=http://localhost:8081
+//	COURSEBOOK_LOGIN=false
+//
+// Example usage:
+//
+//	go run ./cmd/uesstub -addr :8081 -reports ./reports
+//
+// Without -reports, the sample reports in common/ues/uestest/testdata are served.
+package main
+
+import (
+	"flag"
+	"io/fs"
+	"net/http"
+	"os"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/common/ues/uestest"
+)
+
+func main() {
+	addr := flag.String("addr", ":8081", "The address to serve reports on")
+	reportDir := flag.String("reports", "", "A directory of HTML reports to serve instead of the samples")
+	flag.Parse()
+
+	var reports fs.FS = uestest.SampleReports()
+	if *reportDir != "" {
+		reports = os.DirFS(*reportDir)
+	}
+
+	handler, err := uestest.NewStubHandler(reports)
+	if err != nil {
+		log.WriteError(err)
+		os.Exit(1)
+	}
+
+	log.Logger.Info().Str("addr", *addr).Msg("Serving UES reports")
+	if err = http.ListenAndServe(*addr, handler); err != nil {
+		log.WriteError(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,108 @@
+// Package apikey issues and verifies the API keys clients present in the x-api-key header. Keys are kept in a Store, either a Mongo
+// collection or a JSON file, which only holds a hash of each key's secret.
+//
+// A key looks like "nbl_<id>_<secret>": the id is public and looks the key up, and the secret is checked against the stored hash.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+// KEY_PREFIX starts every key, so leaked keys are easy to recognize.
+const KEY_PREFIX = "nbl_"
+
+// Lengths of the random parts of a key, in bytes.
+const (
+	idBytes     = 6
+	secretBytes = 32
+)
+
+var (
+	// ErrInvalidKey is returned for keys that are malformed, unknown or whose secret doesn't match.
+	ErrInvalidKey = errors.New("invalid API key")
+	// ErrExpired is returned for keys past their expiry.
+	ErrExpired = errors.New("the API key has expired")
+	// ErrRevoked is returned for keys that were revoked.
+	ErrRevoked = errors.New("the API key has been revoked")
+	// ErrNotFound is returned by stores for key ids they don't hold.
+	ErrNotFound = errors.New("API key not found")
+)
+
+// Generate issues a new key with the given scopes, which stops working after expiresIn unless it is zero. It returns the key to hand out,
+// which is never stored, and the record to store.
+func Generate(name string, scopes []string, expiresIn time.Duration) (string, schema.APIKey, error) {
+	if len(scopes) == 0 {
+		return "", schema.APIKey{}, errors.New("an API key needs at least one scope")
+	}
+	for _, scope := range scopes {
+		if !schema.ValidAPIKeyScope(scope) {
+			return "", schema.APIKey{}, fmt.Errorf("unknown scope %q, expected one of %s", scope, strings.Join(schema.APIKeyScopes, ", "))
+		}
+	}
+
+	id := make([]byte, idBytes)
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "", schema.APIKey{}, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", schema.APIKey{}, err
+	}
+	encodedSecret := base64.RawURLEncoding.EncodeToString(secret)
+
+	now := time.Now().UTC()
+	key := schema.APIKey{
+		Id:         hex.EncodeToString(id),
+		Name:       name,
+		Hash:       hashSecret(encodedSecret),
+		Scopes:     scopes,
+		Created_at: now,
+	}
+	if expiresIn > 0 {
+		key.Expires_at = now.Add(expiresIn)
+	}
+	return KEY_PREFIX + key.Id + "_" + encodedSecret, key, nil
+}
+
+// Parse splits a key into its id and secret.
+func Parse(token string) (id string, secret string, err error) {
+	rest, ok := strings.CutPrefix(token, KEY_PREFIX)
+	if !ok {
+		return "", "", ErrInvalidKey
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	if !ok || len(id) != 2*idBytes || secret == "" {
+		return "", "", ErrInvalidKey
+	}
+	return id, secret, nil
+}
+
+// Check checks a key's secret against its record, and that the key was neither revoked nor expired at the given time.
+func Check(key schema.APIKey, secret string, now time.Time) error {
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(key.Hash)) != 1 {
+		return ErrInvalidKey
+	}
+	if !key.Revoked_at.IsZero() {
+		return ErrRevoked
+	}
+	if !key.Expires_at.IsZero() && !now.Before(key.Expires_at) {
+		return ErrExpired
+	}
+	return nil
+}
+
+// hashSecret returns the hex SHA-256 hash of a secret. Secrets are long and random, so they don't need a slow hash.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
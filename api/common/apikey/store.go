@@ -0,0 +1,199 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store keeps the issued API keys.
+type Store interface {
+	// Get returns the key with the given id, or ErrNotFound.
+	Get(ctx context.Context, id string) (schema.APIKey, error)
+	// Create stores a newly issued key.
+	Create(ctx context.Context, key schema.APIKey) error
+	// List returns every key, including revoked and expired ones, oldest first.
+	List(ctx context.Context) ([]schema.APIKey, error)
+	// Revoke marks the key with the given id as revoked at the given time, or returns ErrNotFound. Revoking a key again keeps its original
+	// revocation time.
+	Revoke(ctx context.Context, id string, at time.Time) error
+}
+
+// MongoStore keeps keys in a Mongo collection, one document per key.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore returns a store over the given collection.
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+func (store *MongoStore) Get(ctx context.Context, id string) (schema.APIKey, error) {
+	var key schema.APIKey
+	err := store.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return key, ErrNotFound
+	}
+	return key, err
+}
+
+func (store *MongoStore) Create(ctx context.Context, key schema.APIKey) error {
+	_, err := store.collection.InsertOne(ctx, key)
+	return err
+}
+
+func (store *MongoStore) List(ctx context.Context) ([]schema.APIKey, error) {
+	keys := []schema.APIKey{}
+	cursor, err := store.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (store *MongoStore) Revoke(ctx context.Context, id string, at time.Time) error {
+	// Only set the revocation time if there isn't one yet
+	result, err := store.collection.UpdateOne(ctx, bson.M{"_id": id}, mongo.Pipeline{
+		bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$revoked_at", at}}}}}}},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FileStore keeps keys in a JSON file, for deployments without a database of their own to spare. The file is read on every call, so keys
+// issued or revoked by another process take effect, and replaced as a whole on every change.
+type FileStore struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewFileStore returns a store over the given file, which is created on the first change if it doesn't exist.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (store *FileStore) Get(ctx context.Context, id string) (schema.APIKey, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	keys, err := store.read()
+	if err != nil {
+		return schema.APIKey{}, err
+	}
+	for _, key := range keys {
+		if key.Id == id {
+			return key, nil
+		}
+	}
+	return schema.APIKey{}, ErrNotFound
+}
+
+func (store *FileStore) Create(ctx context.Context, key schema.APIKey) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	keys, err := store.read()
+	if err != nil {
+		return err
+	}
+	for _, existing := range keys {
+		if existing.Id == key.Id {
+			return errors.New("an API key with id " + key.Id + " already exists")
+		}
+	}
+	return store.write(append(keys, key))
+}
+
+func (store *FileStore) List(ctx context.Context) ([]schema.APIKey, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	keys, err := store.read()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Created_at.Before(keys[j].Created_at)
+	})
+	return keys, nil
+}
+
+func (store *FileStore) Revoke(ctx context.Context, id string, at time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	keys, err := store.read()
+	if err != nil {
+		return err
+	}
+	for i := range keys {
+		if keys[i].Id != id {
+			continue
+		}
+		if !keys[i].Revoked_at.IsZero() {
+			return nil
+		}
+		keys[i].Revoked_at = at.UTC()
+		return store.write(keys)
+	}
+	return ErrNotFound
+}
+
+// read returns the keys in the file, which holds none if it doesn't exist yet.
+func (store *FileStore) read() ([]schema.APIKey, error) {
+	keys := []schema.APIKey{}
+	data, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return keys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// write replaces the file with the given keys. The keys are written to a temporary file first, so readers never see a partial file.
+func (store *FileStore) write(keys []schema.APIKey) error {
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(store.path), ".apikeys-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err = temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), store.path)
+}
@@ -0,0 +1,252 @@
+package blob
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+// LocalStore keeps each bucket in a directory under its root. An object's content is stored at its name within the bucket's directory, and its
+// description alongside in a .meta directory, which object names can't reach since their segments can't start with a dot.
+type LocalStore struct {
+	root string
+	// Serializes writes to the same object, so an object's content and description always match
+	mutex sync.Mutex
+}
+
+// NewLocalStore creates a store keeping its buckets under root, creating root if needed.
+func NewLocalStore(root string) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{root: root}, nil
+}
+
+func (store *LocalStore) contentPath(bucket string, name string) string {
+	return filepath.Join(store.root, bucket, filepath.FromSlash(name))
+}
+
+func (store *LocalStore) metaPath(bucket string, name string) string {
+	return filepath.Join(store.root, bucket, ".meta", filepath.FromSlash(name)+".json")
+}
+
+// Stat returns the description of an object.
+func (store *LocalStore) Stat(ctx context.Context, bucket string, name string) (schema.ObjectInfo, error) {
+	if !ValidBucketName(bucket) || !ValidObjectName(name) {
+		return schema.ObjectInfo{}, ErrInvalidName
+	}
+	data, err := os.ReadFile(store.metaPath(bucket, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return schema.ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return schema.ObjectInfo{}, err
+	}
+	var info schema.ObjectInfo
+	err = json.Unmarshal(data, &info)
+	return info, err
+}
+
+// Open returns the content and description of an object.
+func (store *LocalStore) Open(ctx context.Context, bucket string, name string) (Object, schema.ObjectInfo, error) {
+	info, err := store.Stat(ctx, bucket, name)
+	if err != nil {
+		return nil, info, err
+	}
+	file, err := os.Open(store.contentPath(bucket, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, info, ErrNotFound
+	}
+	if err != nil {
+		return nil, info, err
+	}
+	return file, info, nil
+}
+
+// Put writes the content to a temporary file first, then moves it into place, so readers never see a partial object.
+func (store *LocalStore) Put(ctx context.Context, bucket string, name string, content io.Reader, contentType string, metadata map[string]string) (schema.ObjectInfo, error) {
+	if !ValidBucketName(bucket) || !ValidObjectName(name) {
+		return schema.ObjectInfo{}, ErrInvalidName
+	}
+
+	if store.conflicts(bucket, name) {
+		return schema.ObjectInfo{}, ErrInvalidName
+	}
+
+	contentPath := store.contentPath(bucket, name)
+	metaPath := store.metaPath(bucket, name)
+	for _, dir := range []string{filepath.Dir(contentPath), filepath.Dir(metaPath)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return schema.ObjectInfo{}, err
+		}
+	}
+
+	temp, err := os.CreateTemp(filepath.Join(store.root, bucket, ".meta"), "upload-*")
+	if err != nil {
+		return schema.ObjectInfo{}, err
+	}
+	defer os.Remove(temp.Name())
+
+	hash := md5.New()
+	size, err := io.Copy(io.MultiWriter(temp, hash), content)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return schema.ObjectInfo{}, err
+	}
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	info := schema.ObjectInfo{
+		Bucket:       bucket,
+		Name:         name,
+		Size:         size,
+		Content_type: contentType,
+		Etag:         hex.EncodeToString(hash.Sum(nil)),
+		Updated_at:   time.Now().UTC(),
+		Metadata:     metadata,
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return info, err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if err = os.Rename(temp.Name(), contentPath); err != nil {
+		return info, err
+	}
+	return info, os.WriteFile(metaPath, data, 0o644)
+}
+
+// conflicts reports whether an object name is already used as a prefix of other objects' names, or starts with another object's name followed by
+// a slash, since a path can't be both a file and a directory.
+func (store *LocalStore) conflicts(bucket string, name string) bool {
+	if info, err := os.Stat(store.contentPath(bucket, name)); err == nil && info.IsDir() {
+		return true
+	}
+	segments := strings.Split(name, "/")
+	for i := 1; i < len(segments); i++ {
+		if info, err := os.Stat(store.contentPath(bucket, strings.Join(segments[:i], "/"))); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// List walks the bucket's .meta directory, skipping whole directories that can't contain a name starting with prefix.
+func (store *LocalStore) List(ctx context.Context, bucket string, prefix string) ([]schema.ObjectInfo, error) {
+	if !ValidBucketName(bucket) {
+		return nil, ErrInvalidName
+	}
+	metaRoot := filepath.Join(store.root, bucket, ".meta")
+	if _, err := os.Stat(filepath.Join(store.root, bucket)); errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	objects := []schema.ObjectInfo{}
+	err := filepath.WalkDir(metaRoot, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(metaRoot, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(relative)
+		if entry.IsDir() {
+			if name != "." && !strings.HasPrefix(name+"/", prefix) && !strings.HasPrefix(prefix, name+"/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name, isMeta := strings.CutSuffix(name, ".json")
+		if !isMeta || !ValidObjectName(name) || !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var info schema.ObjectInfo
+		if err = json.Unmarshal(data, &info); err != nil {
+			return err
+		}
+		objects = append(objects, info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return objects, nil
+}
+
+// Usage walks the bucket's directory without reading any object's description, taking sizes and update times from the content files.
+func (store *LocalStore) Usage(ctx context.Context, bucket string) (schema.BucketInfo, error) {
+	if !ValidBucketName(bucket) {
+		return schema.BucketInfo{}, ErrInvalidName
+	}
+	bucketRoot := filepath.Join(store.root, bucket)
+	if _, err := os.Stat(bucketRoot); errors.Is(err, fs.ErrNotExist) {
+		return schema.BucketInfo{}, ErrNotFound
+	}
+
+	usage := schema.BucketInfo{Name: bucket}
+	err := filepath.WalkDir(bucketRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		// Descriptions and uploads in progress are kept under .meta
+		if strings.HasPrefix(entry.Name(), ".") && path != bucketRoot {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		usage.Object_count++
+		usage.Total_size += info.Size()
+		if updated := info.ModTime().UTC(); updated.After(usage.Updated_at) {
+			usage.Updated_at = updated
+		}
+		return nil
+	})
+	return usage, err
+}
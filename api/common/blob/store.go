@@ -0,0 +1,65 @@
+// Package blob stores the objects served by the storage endpoints. Objects are kept in named buckets behind the BlobStore interface, so the
+// storage backend can change without touching the endpoints. The local filesystem backend is the only one so far.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+var (
+	// ErrNotFound is returned for buckets or objects that don't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidName is returned for bucket or object names that aren't allowed.
+	ErrInvalidName = errors.New("invalid bucket or object name")
+)
+
+// Object is the content of a stored object, which can be read from any offset to serve range requests.
+type Object interface {
+	io.ReadSeekCloser
+}
+
+// BlobStore stores objects in buckets.
+type BlobStore interface {
+	// Stat returns the description of an object.
+	Stat(ctx context.Context, bucket string, name string) (schema.ObjectInfo, error)
+	// Open returns the content and description of an object. The caller must close the content.
+	Open(ctx context.Context, bucket string, name string) (Object, schema.ObjectInfo, error)
+	// Put stores an object, replacing any object with the same name. The bucket is created if it doesn't exist.
+	Put(ctx context.Context, bucket string, name string, content io.Reader, contentType string, metadata map[string]string) (schema.ObjectInfo, error)
+	// List describes every object in a bucket whose name starts with prefix, in name order.
+	List(ctx context.Context, bucket string, prefix string) ([]schema.ObjectInfo, error)
+	// Usage describes a bucket as a whole: how many objects it holds, their total size and when one was last updated. Objects and Prefixes
+	// are left empty.
+	Usage(ctx context.Context, bucket string) (schema.BucketInfo, error)
+}
+
+// Bucket names are lowercase letters, digits, dots, dashes and underscores, starting with a letter or digit.
+var bucketNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,62}$`)
+
+// The longest allowed object name, in bytes.
+const maxObjectNameLength = 1024
+
+// ValidBucketName reports whether a bucket name is allowed.
+func ValidBucketName(bucket string) bool {
+	return bucketNamePattern.MatchString(bucket)
+}
+
+// ValidObjectName reports whether an object name is allowed. Names are slash-separated segments, none of which may be empty or start with a dot.
+// Stores may also refuse names that clash with the names of objects they already hold.
+func ValidObjectName(name string) bool {
+	if name == "" || len(name) > maxObjectNameLength || strings.ContainsAny(name, "\\\x00") {
+		return false
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "" || strings.HasPrefix(segment, ".") {
+			return false
+		}
+	}
+	return true
+}
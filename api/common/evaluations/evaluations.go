@@ -0,0 +1,78 @@
+// Package evaluations scrapes course evaluations from coursebook and stores them in MongoDB, either right away or through a persistent queue of
+// scrape jobs. It's shared by the API, which queues scrapes as evaluations are requested, and the backfill command.
+package evaluations
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/ues"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var evaluationCollection *mongo.Collection = configs.GetCollection("evaluations")
+var sectionCollection *mongo.Collection = configs.GetCollection("sections")
+var courseCollection *mongo.Collection = configs.GetCollection("courses")
+
+// NewSource creates the evaluation source set by the COURSEBOOK_URL and COURSEBOOK_LOGIN environment variables, which logs in through UTD's
+// single sign-on unless disabled, and spaces out its requests by rateLimit.
+func NewSource(rateLimit time.Duration) ues.EvaluationSource {
+	coursebookURL := configs.GetEnvCoursebookURL()
+
+	var login ues.Login
+	if loginURL, ok := configs.GetEnvCoursebookLogin(); ok {
+		login = &ues.ChromeLogin{LoginURL: loginURL, CoursebookURL: coursebookURL, Credentials: configs.GetEnvLogin}
+	}
+	return ues.NewCoursebook(coursebookURL, login, rateLimit)
+}
+
+// Scrape fetches the evaluation of a section from source and stores it. A section without a report is stored as unavailable, which isn't an
+// error since scraping it again won't change the answer. Pages that are neither a report nor coursebook's notice of a missing one return
+// ues.ErrUnrecognizedReport without storing anything, so the scrape can be retried. Missing sections or courses return mongo.ErrNoDocuments.
+func Scrape(ctx context.Context, source ues.EvaluationSource, sectionID primitive.ObjectID) (schema.Evaluation, error) {
+	var section schema.Section
+	var course schema.Course
+
+	err := sectionCollection.FindOne(ctx, bson.M{"_id": sectionID}).Decode(&section)
+	if err != nil {
+		return schema.Evaluation{}, err
+	}
+	err = courseCollection.FindOne(ctx, bson.M{"_id": section.Course_reference}).Decode(&course)
+	if err != nil {
+		return schema.Evaluation{}, err
+	}
+
+	eval, err := source.Fetch(ctx, course, section)
+	if errors.Is(err, ues.ErrNoQuestions) {
+		eval = schema.Evaluation{
+			Id:                   section.Id,
+			CourseExperience:     []schema.EvaluationField{},
+			InstructorExperience: []schema.EvaluationField{},
+			StudentExperience:    []schema.EvaluationField{},
+			Unavailable:          true,
+		}
+	} else if err != nil {
+		return eval, err
+	}
+	eval.Source = source.Name()
+	fetchedAt := time.Now().UTC()
+	eval.Fetched_at = &fetchedAt
+
+	return eval, Store(eval)
+}
+
+// Store replaces the stored evaluation of a section with the given one.
+func Store(eval schema.Evaluation) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := evaluationCollection.ReplaceOne(ctx, bson.M{"_id": eval.Id}, eval, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,227 @@
+package evaluations
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/common/ues"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The scrape_jobs collection is a persistent queue of sections whose evaluations are to be scraped from coursebook, so scraping never happens
+// inside a request and queued work survives restarts. A fixed pool of workers claims jobs from it one at a time. A claimed job holds a lease,
+// and a job whose lease runs out (because its worker or server died) is claimed again by another worker. Failed jobs are retried with
+// exponential backoff until they run out of attempts. Finished jobs are kept for a week so their status can still be looked up.
+var scrapeJobCollection *mongo.Collection = configs.GetCollection("scrape_jobs")
+
+const (
+	// How many times a job is run before it is marked as failed.
+	scrapeJobMaxAttempts = 5
+	// How long to wait before retrying a job after its first failure, doubled after each failure after that up to scrapeJobMaxBackoff.
+	scrapeJobBaseBackoff = 30 * time.Second
+	scrapeJobMaxBackoff  = time.Hour
+	// How long a worker may run a job before it is given up on.
+	scrapeJobLease = 5 * time.Minute
+	// How often idle workers check for jobs that have become due.
+	scrapeJobPollInterval = 5 * time.Second
+	// How long finished jobs are kept.
+	scrapeJobRetention = 7 * 24 * time.Hour
+)
+
+// scrapeJobWake wakes an idle worker as soon as a job is queued, instead of at its next poll.
+var scrapeJobWake = make(chan struct{}, 1)
+
+// StartWorkers starts the pool of workers that run the queued scrape jobs for as long as the server runs. They share a single evaluation
+// source, rate limited by the SCRAPE_RATE_LIMIT environment variable.
+func StartWorkers() {
+	ctx := context.Background()
+
+	_, err := scrapeJobCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "run_after", Value: 1}}},
+		{Keys: bson.D{{Key: "section_reference", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "finished_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(scrapeJobRetention.Seconds()))},
+	})
+	if err != nil {
+		log.WriteError(err)
+	}
+
+	source := NewSource(configs.GetEnvScrapeRateLimit())
+	for i := 0; i < configs.GetEnvEvaluationWorkers(); i++ {
+		go runScrapeWorker(source)
+	}
+}
+
+// Enqueue queues a scrape of a section's evaluation, returning the section's queued or running job instead if it already has one.
+func Enqueue(ctx context.Context, sectionID primitive.ObjectID) (schema.ScrapeJob, error) {
+	var job schema.ScrapeJob
+	now := time.Now().UTC()
+
+	// Two requests racing here can each queue a job for the section, which only costs a redundant scrape
+	err := scrapeJobCollection.FindOneAndUpdate(ctx,
+		bson.D{
+			{Key: "section_reference", Value: sectionID},
+			{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{schema.SCRAPE_JOB_QUEUED, schema.SCRAPE_JOB_RUNNING}}}},
+		},
+		bson.D{{Key: "$setOnInsert", Value: bson.D{
+			{Key: "status", Value: schema.SCRAPE_JOB_QUEUED},
+			{Key: "attempts", Value: 0},
+			{Key: "created_at", Value: now},
+			{Key: "updated_at", Value: now},
+			{Key: "run_after", Value: now},
+		}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&job)
+	if err != nil {
+		return job, err
+	}
+
+	select {
+	case scrapeJobWake <- struct{}{}:
+	default:
+	}
+	return withScrapeJobLinks(job), nil
+}
+
+// FindJob returns the scrape job with the given id, or mongo.ErrNoDocuments if there is none.
+func FindJob(ctx context.Context, id primitive.ObjectID) (schema.ScrapeJob, error) {
+	var job schema.ScrapeJob
+	if err := scrapeJobCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&job); err != nil {
+		return job, err
+	}
+	return withScrapeJobLinks(job), nil
+}
+
+// withScrapeJobLinks fills in where a job's status, and once it has succeeded its evaluation, can be retrieved.
+func withScrapeJobLinks(job schema.ScrapeJob) schema.ScrapeJob {
+	job.Job_uri = "/evaluation/jobs/" + job.Id.Hex()
+	if job.Status == schema.SCRAPE_JOB_SUCCEEDED {
+		job.Evaluation_uri = "/section/" + job.Section_reference.Hex() + "/evaluation"
+	}
+	return job
+}
+
+// runScrapeWorker runs jobs as they become due, one at a time.
+func runScrapeWorker(source ues.EvaluationSource) {
+	for {
+		job, err := claimScrapeJob()
+		if err != nil {
+			log.WriteError(err)
+		}
+		if job == nil {
+			select {
+			case <-scrapeJobWake:
+			case <-time.After(scrapeJobPollInterval):
+			}
+			continue
+		}
+		finishScrapeJob(*job, runScrapeJob(source, *job))
+	}
+}
+
+// claimScrapeJob takes the lease on the job that has been due the longest, returning nil if no job is due.
+func claimScrapeJob() (*schema.ScrapeJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var job schema.ScrapeJob
+	now := time.Now().UTC()
+
+	err := scrapeJobCollection.FindOneAndUpdate(ctx,
+		bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "status", Value: schema.SCRAPE_JOB_QUEUED}, {Key: "run_after", Value: bson.D{{Key: "$lte", Value: now}}}},
+			bson.D{{Key: "status", Value: schema.SCRAPE_JOB_RUNNING}, {Key: "lease_until", Value: bson.D{{Key: "$lt", Value: now}}}},
+		}}},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "status", Value: schema.SCRAPE_JOB_RUNNING},
+				{Key: "lease_until", Value: now.Add(scrapeJobLease)},
+				{Key: "updated_at", Value: now},
+			}},
+			{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+		},
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "run_after", Value: 1}}).SetReturnDocument(options.After),
+	).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// runScrapeJob scrapes the evaluation of a job's section and stores it.
+func runScrapeJob(source ues.EvaluationSource, job schema.ScrapeJob) error {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeJobLease)
+	defer cancel()
+
+	_, err := Scrape(ctx, source, job.Section_reference)
+	return err
+}
+
+// finishScrapeJob records the outcome of a run of a job, queueing it to be retried after a backoff if it failed and has attempts left. Missing
+// sections or courses fail the job right away since retrying can't help. Nothing is recorded if the job's lease ran out and another worker
+// claimed it in the meantime.
+func finishScrapeJob(job schema.ScrapeJob, jobErr error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	var update bson.D
+
+	switch {
+	case jobErr == nil:
+		update = bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "status", Value: schema.SCRAPE_JOB_SUCCEEDED},
+				{Key: "updated_at", Value: now},
+				{Key: "finished_at", Value: now},
+			}},
+			{Key: "$unset", Value: bson.D{{Key: "last_error", Value: ""}, {Key: "lease_until", Value: ""}}},
+		}
+	case errors.Is(jobErr, mongo.ErrNoDocuments) || job.Attempts >= scrapeJobMaxAttempts:
+		log.WriteError(jobErr)
+		update = bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "status", Value: schema.SCRAPE_JOB_FAILED},
+				{Key: "last_error", Value: jobErr.Error()},
+				{Key: "updated_at", Value: now},
+				{Key: "finished_at", Value: now},
+			}},
+			{Key: "$unset", Value: bson.D{{Key: "lease_until", Value: ""}}},
+		}
+	default:
+		log.WriteError(jobErr)
+		update = bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "status", Value: schema.SCRAPE_JOB_QUEUED},
+				{Key: "last_error", Value: jobErr.Error()},
+				{Key: "updated_at", Value: now},
+				{Key: "run_after", Value: now.Add(scrapeJobBackoff(job.Attempts))},
+			}},
+			{Key: "$unset", Value: bson.D{{Key: "lease_until", Value: ""}}},
+		}
+	}
+
+	_, err := scrapeJobCollection.UpdateOne(ctx, bson.M{"_id": job.Id, "lease_until": job.Lease_until}, update)
+	if err != nil {
+		log.WriteError(err)
+	}
+}
+
+// scrapeJobBackoff returns how long to wait before retrying a job that has failed the given number of times.
+func scrapeJobBackoff(attempts int) time.Duration {
+	backoff := scrapeJobBaseBackoff
+	for i := 1; i < attempts && backoff < scrapeJobMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, scrapeJobMaxBackoff)
+}
@@ -0,0 +1,180 @@
+// Package ical writes iCalendar (RFC 5545) feeds, which calendar apps can subscribe to by URL. Only the parts of the format needed to publish
+// events are supported: a calendar of VEVENT components with times in UTC.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// PRODUCT_ID identifies the API as the program that wrote a feed.
+const PRODUCT_ID = "-//UTD Nebula//Nebula API//EN"
+
+// Lines longer than this many octets are folded onto continuation lines.
+const maxLineOctets = 75
+
+// Layout of date-time values in UTC.
+const utcLayout = "20060102T150405Z"
+
+// Calendar is a feed of events.
+type Calendar struct {
+	// Name and Description are shown by calendar apps for the subscribed calendar.
+	Name        string
+	Description string
+	// RefreshInterval suggests how often subscribers should fetch the feed again. Zero leaves it up to them.
+	RefreshInterval time.Duration
+	Events          []Event
+}
+
+// Event is a single event of a calendar.
+type Event struct {
+	// UID identifies the event across fetches of the feed, so updates replace it instead of duplicating it.
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	// End is optional; events without one are taken to last no time at all.
+	End        time.Time
+	Categories []string
+	Contact    string
+	// LastModified is when the event was last changed, which subscribers compare to pick up edits. Zero leaves it out.
+	LastModified time.Time
+}
+
+// Write writes a calendar in iCalendar format, stamping every event with the given time.
+func Write(w io.Writer, calendar Calendar, stamp time.Time) error {
+	out := lineWriter{w: bufio.NewWriter(w)}
+
+	out.line("BEGIN", "VCALENDAR")
+	out.line("VERSION", "2.0")
+	out.line("PRODID", PRODUCT_ID)
+	out.line("CALSCALE", "GREGORIAN")
+	out.line("METHOD", "PUBLISH")
+	if calendar.Name != "" {
+		out.line("X-WR-CALNAME", escapeText(calendar.Name))
+	}
+	if calendar.Description != "" {
+		out.line("X-WR-CALDESC", escapeText(calendar.Description))
+	}
+	if calendar.RefreshInterval > 0 {
+		interval := duration(calendar.RefreshInterval)
+		out.line("REFRESH-INTERVAL;VALUE=DURATION", interval)
+		out.line("X-PUBLISHED-TTL", interval)
+	}
+
+	for _, event := range calendar.Events {
+		out.line("BEGIN", "VEVENT")
+		out.line("UID", escapeText(event.UID))
+		out.line("DTSTAMP", stamp.UTC().Format(utcLayout))
+		if !event.LastModified.IsZero() {
+			out.line("LAST-MODIFIED", event.LastModified.UTC().Format(utcLayout))
+		}
+		out.line("DTSTART", event.Start.UTC().Format(utcLayout))
+		if !event.End.IsZero() && event.End.After(event.Start) {
+			out.line("DTEND", event.End.UTC().Format(utcLayout))
+		}
+		out.line("SUMMARY", escapeText(event.Summary))
+		if event.Description != "" {
+			out.line("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.Location != "" {
+			out.line("LOCATION", escapeText(event.Location))
+		}
+		if event.URL != "" {
+			// URIs aren't escaped, but mustn't break the line
+			out.line("URL", strings.Join(strings.Fields(event.URL), ""))
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escapeText(category)
+			}
+			out.line("CATEGORIES", strings.Join(categories, ","))
+		}
+		if event.Contact != "" {
+			out.line("CONTACT", escapeText(event.Contact))
+		}
+		out.line("END", "VEVENT")
+	}
+
+	out.line("END", "VCALENDAR")
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// lineWriter writes content lines, folding long ones and keeping the first error.
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes a content line, folding it after every 75 octets without splitting a UTF-8 character. Continuation lines start with a space,
+// which counts towards their length. Invalid UTF-8 with no character start to cut before is cut at the limit.
+func (out *lineWriter) line(name string, value string) {
+	if out.err != nil {
+		return
+	}
+	content := name + ":" + value
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			cut = limit
+		}
+		if _, out.err = out.w.WriteString(content[:cut] + "\r\n "); out.err != nil {
+			return
+		}
+		content = content[cut:]
+		limit = maxLineOctets - 1
+	}
+	_, out.err = out.w.WriteString(content + "\r\n")
+}
+
+// textEscaper escapes the characters that have a meaning in TEXT values. Carriage returns are dropped, since newlines are escaped on their own.
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "")
+
+// escapeText escapes a TEXT value.
+func escapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+// duration formats a duration as an iCalendar DURATION value, to the second.
+func duration(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	days, seconds := seconds/86400, seconds%86400
+	hours, seconds := seconds/3600, seconds%3600
+	minutes, seconds := seconds/60, seconds%60
+
+	var builder strings.Builder
+	builder.WriteString("P")
+	if days > 0 {
+		builder.WriteString(strconv.FormatInt(days, 10) + "D")
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 {
+		builder.WriteString("T")
+		if hours > 0 {
+			builder.WriteString(strconv.FormatInt(hours, 10) + "H")
+		}
+		if minutes > 0 {
+			builder.WriteString(strconv.FormatInt(minutes, 10) + "M")
+		}
+		if seconds > 0 {
+			builder.WriteString(strconv.FormatInt(seconds, 10) + "S")
+		}
+	}
+	if builder.Len() == 1 {
+		return "PT0S"
+	}
+	return builder.String()
+}
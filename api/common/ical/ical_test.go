@@ -0,0 +1,186 @@
+package ical
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// writeLine writes a single content line and returns its physical lines without their line breaks.
+func writeLine(t *testing.T, name string, value string) []string {
+	t.Helper()
+	var buffer bytes.Buffer
+	out := lineWriter{w: bufio.NewWriter(&buffer)}
+	out.line(name, value)
+	if out.err != nil {
+		t.Fatal(out.err)
+	}
+	if err := out.w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	text := buffer.String()
+	if !strings.HasSuffix(text, "\r\n") {
+		t.Fatalf("line %q doesn't end with CRLF", text)
+	}
+	return strings.Split(strings.TrimSuffix(text, "\r\n"), "\r\n")
+}
+
+// unfold joins folded lines back into the content line.
+func unfold(lines []string) string {
+	content := lines[0]
+	for _, line := range lines[1:] {
+		content += strings.TrimPrefix(line, " ")
+	}
+	return content
+}
+
+func TestLineFolding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		// lengths of the physical lines in octets, including the leading space of continuation lines
+		lengths []int
+		valid   bool
+	}{
+		{"short", "Tea", []int{11}, true},
+		{"exactly the limit", strings.Repeat("a", 67), []int{75}, true},
+		{"one over the limit", strings.Repeat("a", 68), []int{75, 2}, true},
+		{"several continuations", strings.Repeat("a", 67+74+10), []int{75, 75, 11}, true},
+		// "é" is two octets, so the 75th octet falls in the middle of one
+		{"multibyte character at the fold", strings.Repeat("a", 66) + strings.Repeat("é", 10), []int{74, 21}, true},
+		// the name is folded off before the first byte that starts a character, then the rest is cut at the limit
+		{"invalid UTF-8", strings.Repeat("\x80", 200), []int{7, 75, 75, 54}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := writeLine(t, "SUMMARY", test.value)
+			lengths := make([]int, len(lines))
+			for i, line := range lines {
+				lengths[i] = len(line)
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d %q doesn't start with a space", i, line)
+				}
+				if test.valid && !utf8.ValidString(line) {
+					t.Errorf("line %d %q splits a character", i, line)
+				}
+			}
+			if len(lengths) != len(test.lengths) {
+				t.Fatalf("got lines of %v octets, want %v", lengths, test.lengths)
+			}
+			for i := range lengths {
+				if lengths[i] != test.lengths[i] {
+					t.Fatalf("got lines of %v octets, want %v", lengths, test.lengths)
+				}
+			}
+			if got, want := unfold(lines), "SUMMARY:"+test.value; got != want {
+				t.Errorf("unfolded to %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Career Fair", "Career Fair"},
+		{`C:\Users`, `C:\\Users`},
+		{"Food; drinks, games", `Food\; drinks\, games`},
+		{"line one\nline two", `line one\nline two`},
+		{"line one\r\nline two", `line one\nline two`},
+		{"stray\rreturn", "strayreturn"},
+		{`\;`, `\\\;`},
+	}
+
+	for _, test := range tests {
+		if got := escapeText(test.text); got != test.want {
+			t.Errorf("escapeText(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{0, "PT0S"},
+		{500 * time.Millisecond, "PT0S"},
+		{45 * time.Second, "PT45S"},
+		{time.Hour, "PT1H"},
+		{90 * time.Minute, "PT1H30M"},
+		{24 * time.Hour, "P1D"},
+		{26*time.Hour + 5*time.Second, "P1DT2H5S"},
+	}
+
+	for _, test := range tests {
+		if got := duration(test.duration); got != test.want {
+			t.Errorf("duration(%v) = %q, want %q", test.duration, got, test.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	stamp := time.Date(2024, time.September, 1, 12, 0, 0, 0, time.UTC)
+	central := time.FixedZone("CDT", -5*60*60)
+	calendar := Calendar{
+		Name:            "Events",
+		RefreshInterval: 6 * time.Hour,
+		Events: []Event{
+			{
+				UID:          "1@example.com",
+				Summary:      "Hackathon, day one",
+				Start:        time.Date(2024, time.September, 7, 9, 0, 0, 0, central),
+				End:          time.Date(2024, time.September, 7, 17, 0, 0, 0, central),
+				Categories:   []string{"Tech", "Food, free"},
+				LastModified: time.Date(2024, time.August, 30, 8, 15, 0, 0, time.UTC),
+			},
+			{
+				UID:     "2@example.com",
+				Summary: "Deadline",
+				Start:   time.Date(2024, time.September, 9, 23, 59, 0, 0, time.UTC),
+				URL:     "https://example.com/a b",
+			},
+		},
+	}
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, calendar, stamp); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + PRODUCT_ID,
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Events",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT6H",
+		"X-PUBLISHED-TTL:PT6H",
+		"BEGIN:VEVENT",
+		"UID:1@example.com",
+		"DTSTAMP:20240901T120000Z",
+		"LAST-MODIFIED:20240830T081500Z",
+		"DTSTART:20240907T140000Z",
+		"DTEND:20240907T220000Z",
+		`SUMMARY:Hackathon\, day one`,
+		`CATEGORIES:Tech,Food\, free`,
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:2@example.com",
+		"DTSTAMP:20240901T120000Z",
+		"DTSTART:20240909T235900Z",
+		"SUMMARY:Deadline",
+		"URL:https://example.com/ab",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+	if got := buffer.String(); got != want {
+		t.Errorf("Write wrote\n%s\nwant\n%s", got, want)
+	}
+}
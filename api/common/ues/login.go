@@ -0,0 +1,129 @@
+package ues
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// How long a coursebook session cookie is reused before logging in again.
+const tokenLifetime time.Duration = time.Minute * 15
+
+// ChromeLogin logs in to coursebook through UTD's single sign-on with a headless browser, and reuses the session cookie it gets for
+// tokenLifetime. The login flow is copied from API-Tools.
+type ChromeLogin struct {
+	// LoginURL is the sign-on page with the login form.
+	LoginURL string
+	// CoursebookURL is visited after logging in to collect its session cookie.
+	CoursebookURL string
+	// Credentials returns the NetID and password to log in with. It's only called when logging in.
+	Credentials func() (netID string, password string)
+
+	mutex         sync.Mutex
+	lastTokenTime time.Time
+	cachedCookie  http.Header
+}
+
+// Headers returns the headers for authenticated requests to coursebook, logging in when the cached cookie has expired. It uses a mutex lock so
+// that concurrent scrapes wait for a single login instead of each starting their own.
+func (login *ChromeLogin) Headers(ctx context.Context) (http.Header, error) {
+
+	// Multiple workers may try to refresh their token simultaneously; wrap this area in a mutex lock so as to avoid overlapping refreshes
+	login.mutex.Lock()
+	defer login.mutex.Unlock()
+
+	// Just return the last cached cookie while it's still valid
+	if login.cachedCookie != nil && time.Since(login.lastTokenTime) < tokenLifetime {
+		return login.cachedCookie.Clone(), nil
+	}
+
+	coursebookURL, err := url.Parse(login.CoursebookURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start a browser just for logging in
+	chromedpCtx, cancel := initChromeDp(ctx)
+	defer cancel()
+
+	netID, password := login.Credentials()
+
+	log.WriteDebug("Getting new token...")
+	// Clear browser cookies, navigate to the login page, and authenticate using chromedp.
+	_, err = chromedp.RunResponse(chromedpCtx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			err := network.ClearBrowserCookies().Do(ctx)
+			return err
+		}),
+		chromedp.Navigate(login.LoginURL),
+		chromedp.WaitVisible(`form#login-form`),
+		chromedp.SendKeys(`input#netid`, netID),
+		chromedp.SendKeys(`input#password`, password),
+		chromedp.Click(`input#login-button`),
+		chromedp.WaitVisible(`body`),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve cookies from the browser session after login.
+	var cookieStrs []string
+	_, err = chromedp.RunResponse(chromedpCtx,
+		chromedp.Navigate(login.CoursebookURL),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			cookies, err := network.GetCookies().Do(ctx)
+			cookieStrs = make([]string, len(cookies))
+			gotToken := false
+			for i, cookie := range cookies {
+				cookieStrs[i] = fmt.Sprintf("%s=%s", cookie.Name, cookie.Value)
+				if cookie.Name == "PTGSESSID" {
+					log.WriteDebug(fmt.Sprintf("Got new token: PTGSESSID = %s", cookie.Value))
+					gotToken = true
+				}
+			}
+			if !gotToken {
+				return errors.New("failed to get a new token")
+			}
+			return err
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the retrieved cookies for future requests.
+	login.cachedCookie = http.Header{
+		"Host":            {coursebookURL.Host},
+		"User-Agent":      {"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:109.0) Gecko/20100101 Firefox/110.0"},
+		"Accept":          {"text/html"},
+		"Accept-Language": {"en-US"},
+		"Content-Type":    {"application/x-www-form-urlencoded"},
+		"Cookie":          cookieStrs,
+		"Connection":      {"keep-alive"},
+	}
+	login.lastTokenTime = time.Now()
+
+	// Return the updated cookie headers
+	return login.cachedCookie.Clone(), nil
+}
+
+// Initializes chromedp using the default executable allocator. Canceling the returned context closes the browser.
+func initChromeDp(ctx context.Context) (chromedpCtx context.Context, cancelFnc context.CancelFunc) {
+	log.WriteDebug("Initializing chromedp...")
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx)
+	chromedpCtx, cancelCtx := chromedp.NewContext(allocCtx)
+	log.WriteDebug("Initialized chromedp!")
+	return chromedpCtx, func() {
+		cancelCtx()
+		cancelAlloc()
+	}
+}
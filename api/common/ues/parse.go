@@ -0,0 +1,313 @@
+// Package ues fetches the UES (course evaluation) reports published on UTD's coursebook and parses them into schema.Evaluation documents.
+//
+// A report groups its questions under a Course Experience, Instructor Experience and Student Experience heading, each followed by a table with
+// one row per question and one column per Likert response. A response cell holds the number of students who gave it, optionally followed by
+// their percentage, e.g. "12 (40.00%)". Headings are recognized by their text wherever they appear: as a heading element, a table caption, or
+// a row with a single cell inside the table. Columns are recognized by their header text, so their order and any extra columns (such as the
+// report's own statistics, which are recomputed from the counts) don't matter.
+//
+// Reports saved from coursebook go in testdata/coursebook, each next to the evaluation it should parse into, so the parser is checked against
+// coursebook's own output. Hand-built samples of each layout the parser supports are kept as extra cases in the uestest package, whose stub
+// server serves them in coursebook's place.
+package ues
+
+import (
+	"errors"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// ErrNoQuestions is returned for the notice coursebook shows in place of a report for sections that weren't evaluated.
+	ErrNoQuestions = errors.New("the report doesn't contain any evaluation questions")
+	// ErrUnrecognizedReport is returned for pages that have no questions but aren't coursebook's notice either, such as a sign-on page after
+	// the session ran out, an error page, or a report in a layout the parser doesn't know. Unlike ErrNoQuestions, it says nothing about
+	// whether the section has a report, so fetching it again may succeed.
+	ErrUnrecognizedReport = errors.New("the page isn't a recognized UES report")
+)
+
+// category is a group of questions in a report.
+type category int
+
+const (
+	noCategory category = iota
+	courseCategory
+	instructorCategory
+	studentCategory
+)
+
+// categoryOf returns the category introduced by a heading's text.
+func categoryOf(text string) (category, bool) {
+	text = strings.ToLower(text)
+	switch {
+	case strings.Contains(text, "course experience"):
+		return courseCategory, true
+	case strings.Contains(text, "instructor experience"):
+		return instructorCategory, true
+	case strings.Contains(text, "student experience"), strings.Contains(text, "student self"):
+		return studentCategory, true
+	}
+	return noCategory, false
+}
+
+// likertColumns maps the letters of a column heading to the response it holds.
+var likertColumns = map[string]schema.EvaluationResponse{
+	"stronglydisagree":        schema.STRONGLY_DISAGREE,
+	"disagree":                schema.DISAGREE,
+	"neutral":                 schema.NEUTRAL,
+	"neitheragreenordisagree": schema.NEUTRAL,
+	"agree":                   schema.AGREE,
+	"stronglyagree":           schema.STRONGLY_AGREE,
+}
+
+// Matches the notice coursebook shows for sections without a report, e.g. "There is no evaluation report available for this section."
+var noReportPattern = regexp.MustCompile(`(?i)\bno (?:evaluation |ues )?report (?:is )?available\b`)
+
+// Matches a response cell: the count, then optionally the percentage.
+var responseCellPattern = regexp.MustCompile(`^(\d+)\s*(?:\(?\s*(\d+(?:\.\d+)?)\s*%\s*\)?)?$`)
+
+// Parse reads a UES report into an evaluation. The evaluation's id is left for the caller to set to the section's. Pages without questions
+// return ErrNoQuestions only when they carry coursebook's notice that the section has no report, and ErrUnrecognizedReport otherwise.
+func Parse(r io.Reader) (schema.Evaluation, error) {
+	evaluation := schema.Evaluation{
+		CourseExperience:     []schema.EvaluationField{},
+		InstructorExperience: []schema.EvaluationField{},
+		StudentExperience:    []schema.EvaluationField{},
+	}
+
+	doc, err := html.Parse(r)
+	if err != nil {
+		return evaluation, err
+	}
+
+	parser := reportParser{evaluation: &evaluation}
+	parser.walk(doc)
+
+	if len(evaluation.CourseExperience)+len(evaluation.InstructorExperience)+len(evaluation.StudentExperience) == 0 {
+		if noReportPattern.MatchString(textContent(doc)) {
+			return evaluation, ErrNoQuestions
+		}
+		return evaluation, ErrUnrecognizedReport
+	}
+	return evaluation, nil
+}
+
+// reportParser walks a report's document in order, keeping track of the category of the most recent heading.
+type reportParser struct {
+	evaluation *schema.Evaluation
+	current    category
+}
+
+func (p *reportParser) walk(n *html.Node) {
+	if n.Type == html.ElementNode {
+		switch n.DataAtom {
+		case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Legend:
+			if c, ok := categoryOf(textContent(n)); ok {
+				p.current = c
+			}
+			return
+		case atom.Table:
+			p.parseTable(n)
+			return
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		p.walk(child)
+	}
+}
+
+// parseTable adds the questions of a table to the evaluation. Tables without Likert response columns are skipped.
+func (p *reportParser) parseTable(table *html.Node) {
+	columns := make(map[int]schema.EvaluationResponse)
+
+	for _, row := range tableRows(table) {
+		if row.DataAtom == atom.Caption {
+			if c, ok := categoryOf(textContent(row)); ok {
+				p.current = c
+			}
+			continue
+		}
+
+		cells := rowCells(row)
+
+		// A row with a single cell is a heading within the table
+		if len(cells) == 1 {
+			if c, ok := categoryOf(cells[0].text); ok {
+				p.current = c
+			}
+			continue
+		}
+
+		// A row naming at least three responses is the header row
+		if header := headerColumns(cells); len(header) >= 3 {
+			columns = header
+			continue
+		}
+		if len(columns) == 0 || p.current == noCategory || len(cells) == 0 {
+			continue
+		}
+
+		if field, ok := parseQuestion(cells, columns); ok {
+			switch p.current {
+			case courseCategory:
+				p.evaluation.CourseExperience = append(p.evaluation.CourseExperience, field)
+			case instructorCategory:
+				p.evaluation.InstructorExperience = append(p.evaluation.InstructorExperience, field)
+			case studentCategory:
+				p.evaluation.StudentExperience = append(p.evaluation.StudentExperience, field)
+			}
+		}
+	}
+}
+
+// headerColumns returns the column of each Likert response a row names.
+func headerColumns(cells []tableCell) map[int]schema.EvaluationResponse {
+	columns := make(map[int]schema.EvaluationResponse)
+	for _, cell := range cells {
+		if response, ok := likertColumns[lettersOf(cell.text)]; ok {
+			columns[cell.column] = response
+		}
+	}
+	return columns
+}
+
+// parseQuestion reads a question row into an evaluation field. The question is the row's first cell, and rows without any response counts
+// aren't questions.
+func parseQuestion(cells []tableCell, columns map[int]schema.EvaluationResponse) (schema.EvaluationField, bool) {
+	field := schema.EvaluationField{
+		Description: cells[0].text,
+		Counts:      make(map[schema.EvaluationResponse]int),
+		Percentages: make(map[schema.EvaluationResponse]float32),
+	}
+
+	reportedPercentages := true
+	found := false
+	for _, cell := range cells[1:] {
+		response, ok := columns[cell.column]
+		if !ok {
+			continue
+		}
+		match := responseCellPattern.FindStringSubmatch(strings.ReplaceAll(cell.text, ",", ""))
+		if match == nil {
+			continue
+		}
+		found = true
+
+		field.Counts[response], _ = strconv.Atoi(match[1])
+		if match[2] != "" {
+			percentage, _ := strconv.ParseFloat(match[2], 32)
+			field.Percentages[response] = float32(percentage)
+		} else {
+			reportedPercentages = false
+		}
+	}
+	if !found || field.Description == "" {
+		return field, false
+	}
+
+	// Every response is listed, even those nobody gave
+	for _, response := range schema.LikertResponses {
+		if _, ok := field.Counts[response]; !ok {
+			field.Counts[response] = 0
+			reportedPercentages = false
+		}
+	}
+
+	field.Summary = schema.NewEvaluationSummary(field.Counts)
+	if !reportedPercentages {
+		for _, response := range schema.LikertResponses {
+			field.Percentages[response] = 0
+			if field.Summary.Responses > 0 {
+				percentage := float64(field.Counts[response]) / float64(field.Summary.Responses) * 100
+				field.Percentages[response] = float32(math.Round(percentage*100) / 100)
+			}
+		}
+	}
+	return field, true
+}
+
+// tableCell is a cell of a table row along with the column it starts in, accounting for the cells before it that span several columns.
+type tableCell struct {
+	column int
+	text   string
+}
+
+// tableRows returns the caption and rows of a table in order, without descending into nested tables.
+func tableRows(table *html.Node) []*html.Node {
+	var rows []*html.Node
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Caption, atom.Tr:
+				rows = append(rows, child)
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collect(child)
+			}
+		}
+	}
+	collect(table)
+	return rows
+}
+
+// rowCells returns the cells of a table row.
+func rowCells(row *html.Node) []tableCell {
+	var cells []tableCell
+	column := 0
+	for child := row.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || (child.DataAtom != atom.Td && child.DataAtom != atom.Th) {
+			continue
+		}
+		cells = append(cells, tableCell{column: column, text: textContent(child)})
+
+		span := 1
+		for _, attr := range child.Attr {
+			if attr.Key == "colspan" {
+				if value, err := strconv.Atoi(attr.Val); err == nil && value > 1 {
+					span = value
+				}
+			}
+		}
+		column += span
+	}
+	return cells
+}
+
+// textContent returns the text within a node with its whitespace collapsed.
+func textContent(n *html.Node) string {
+	var builder strings.Builder
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			builder.WriteString(n.Data)
+			builder.WriteString(" ")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			collect(child)
+		}
+	}
+	collect(n)
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// lettersOf returns the lowercase letters of a string, dropping everything else.
+func lettersOf(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, text)
+}
@@ -0,0 +1,110 @@
+package ues
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+// With -update, TestSavedReports writes what each saved report parses into next to it, to be checked by hand before committing.
+var update = flag.Bool("update", false, "write the expected evaluations of the saved coursebook reports")
+
+func parseFixture(t *testing.T, name string) (schema.Evaluation, error) {
+	t.Helper()
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+func TestParseUnrecognized(t *testing.T) {
+	pages := map[string]string{
+		"sign-on page": `<html><body><h1>UTD Single Sign-On</h1><form id="login-form"><input id="netid"><input id="password" type="password"></form></body></html>`,
+		"error page":   `<html><body><h1>Coursebook is temporarily unavailable</h1><p>Please try again later.</p></body></html>`,
+		"new layout":   `<html><body><h2>Course Experience</h2><div class="question">The course was well organized.<span>4.2</span></div></body></html>`,
+		"empty page":   ``,
+	}
+	for name, page := range pages {
+		if _, err := Parse(strings.NewReader(page)); !errors.Is(err, ErrUnrecognizedReport) {
+			t.Errorf("%s: got error %v, want ErrUnrecognizedReport", name, err)
+		}
+	}
+}
+
+// TestSavedReports parses each report saved from coursebook in testdata/coursebook, {id}.html, and compares it with the evaluation in {id}.json.
+// Coursebook's notice for a section without a report is saved with {"unavailable": true} in place of an evaluation.
+func TestSavedReports(t *testing.T) {
+	const savedNotice = `{"unavailable": true}`
+
+	reports, err := filepath.Glob(filepath.Join("testdata", "coursebook", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) == 0 {
+		t.Skip("no reports saved from coursebook in testdata/coursebook")
+	}
+
+	for _, report := range reports {
+		name := filepath.Base(report)
+		expectedPath := strings.TrimSuffix(report, ".html") + ".json"
+
+		var got []byte
+		evaluation, err := parseFixture(t, filepath.Join("coursebook", name))
+		if errors.Is(err, ErrNoQuestions) {
+			got = []byte(savedNotice)
+		} else if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		} else if got, err = json.MarshalIndent(evaluation, "", "\t"); err != nil {
+			t.Fatal(err)
+		}
+
+		if *update {
+			if err = os.WriteFile(expectedPath, append(got, '\n'), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		data, err := os.ReadFile(expectedPath)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		var notice struct {
+			Unavailable bool `json:"unavailable"`
+		}
+		if err = json.Unmarshal(data, &notice); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if notice.Unavailable {
+			if string(got) != savedNotice {
+				t.Errorf("%s: got an evaluation, want coursebook's notice of a missing report", name)
+			}
+			continue
+		}
+
+		// Round trip the expected evaluation so both are written the same way
+		var expected schema.Evaluation
+		if err = json.Unmarshal(data, &expected); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		want, err := json.MarshalIndent(expected, "", "\t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s: got evaluation\n%s\nwant\n%s", name, got, want)
+		}
+	}
+}
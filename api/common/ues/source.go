@@ -0,0 +1,137 @@
+package ues
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+// EvaluationSource fetches the UES reports of sections.
+type EvaluationSource interface {
+	// Name identifies the source in the evaluations fetched from it.
+	Name() string
+	// Fetch returns the evaluation of a section, or ErrNoQuestions if the section wasn't evaluated.
+	Fetch(ctx context.Context, course schema.Course, section schema.Section) (schema.Evaluation, error)
+}
+
+// Login authenticates requests to coursebook.
+type Login interface {
+	// Headers returns the headers to send with each request, logging in first if needed.
+	Headers(ctx context.Context) (http.Header, error)
+}
+
+// The host of UTD's coursebook.
+const coursebookHost = "coursebook.utdallas.edu"
+
+// ReportID returns the id coursebook files a section's report under, e.g. "CS1337.001.23F".
+func ReportID(course schema.Course, section schema.Section) string {
+	return course.Subject_prefix + course.Course_number + "." + section.Section_number + "." + section.Academic_session.Name
+}
+
+// Matches a report id: the subject prefix and course number, then the section number and academic session.
+var reportIDPattern = regexp.MustCompile(`^([A-Za-z]+)([0-9][0-9A-Za-z]*)\.([0-9A-Za-z]+)\.([0-9A-Za-z]+)$`)
+
+// ParseReportID splits a report id back into the subject prefix, course number, section number and academic session it was built from.
+func ParseReportID(id string) (subjectPrefix string, courseNumber string, sectionNumber string, session string, err error) {
+	match := reportIDPattern.FindStringSubmatch(id)
+	if match == nil {
+		return "", "", "", "", fmt.Errorf("%q isn't a report id", id)
+	}
+	return strings.ToUpper(match[1]), strings.ToUpper(match[2]), strings.ToUpper(match[3]), strings.ToUpper(match[4]), nil
+}
+
+// Coursebook fetches reports over HTTP from coursebook, or from anything serving reports at the same paths such as the stub server. Requests
+// to each host are spaced out by the rate limit, however many goroutines share the source.
+type Coursebook struct {
+	baseURL string
+	login   Login
+	client  *http.Client
+	limiter hostRateLimiter
+}
+
+// NewCoursebook creates a source fetching reports from the coursebook at baseURL. Requests are sent without authentication when login is nil.
+func NewCoursebook(baseURL string, login Login, rateLimit time.Duration) *Coursebook {
+	return &Coursebook{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		login:   login,
+		client:  &http.Client{Timeout: time.Minute},
+		limiter: hostRateLimiter{interval: rateLimit, next: make(map[string]time.Time)},
+	}
+}
+
+// Name returns "coursebook" for UTD's coursebook, and the base URL for anything else so evaluations from a stub are told apart.
+func (source *Coursebook) Name() string {
+	if baseURL, err := url.Parse(source.baseURL); err == nil && baseURL.Hostname() == coursebookHost {
+		return schema.EVALUATION_SOURCE_COURSEBOOK
+	}
+	return source.baseURL
+}
+
+// Fetch downloads and parses the report of a section.
+func (source *Coursebook) Fetch(ctx context.Context, course schema.Course, section schema.Section) (schema.Evaluation, error) {
+	reportURL := source.baseURL + "/ues-report/" + url.PathEscape(ReportID(course, section))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reportURL, nil)
+	if err != nil {
+		return schema.Evaluation{}, err
+	}
+	if source.login != nil {
+		if req.Header, err = source.login.Headers(ctx); err != nil {
+			return schema.Evaluation{}, err
+		}
+	}
+	if err = source.limiter.wait(ctx, req.URL.Host); err != nil {
+		return schema.Evaluation{}, err
+	}
+
+	res, err := source.client.Do(req)
+	if err != nil {
+		return schema.Evaluation{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return schema.Evaluation{}, fmt.Errorf("section find failed! Status was: %s\nIf the status is 404, you've likely been IP ratelimited", res.Status)
+	}
+
+	// Parse the report into an evaluation of the section
+	evaluation, err := Parse(res.Body)
+	if err != nil {
+		return evaluation, err
+	}
+	evaluation.Id = section.Id
+	return evaluation, nil
+}
+
+// hostRateLimiter spaces out requests to each host by at least its interval.
+type hostRateLimiter struct {
+	interval time.Duration
+	mutex    sync.Mutex
+	next     map[string]time.Time
+}
+
+// wait blocks until a request may be made to the host, reserving that slot so the next caller waits for the one after.
+func (limiter *hostRateLimiter) wait(ctx context.Context, host string) error {
+	limiter.mutex.Lock()
+	slot := limiter.next[host]
+	if now := time.Now(); slot.Before(now) {
+		slot = now
+	}
+	limiter.next[host] = slot.Add(limiter.interval)
+	limiter.mutex.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,102 @@
+package uestest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/UTDNebula/nebula-api/api/common/ues"
+	"github.com/UTDNebula/nebula-api/api/schema"
+)
+
+// expectedQuestion is a question a fixture should parse into, with its counts and percentages listed from strongly disagree to strongly agree.
+type expectedQuestion struct {
+	description string
+	counts      [5]int
+	percentages [5]float32
+}
+
+// parseSample parses one of the sample reports.
+func parseSample(t *testing.T, name string) (schema.Evaluation, error) {
+	t.Helper()
+	file, err := SampleReports().Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	return ues.Parse(file)
+}
+
+func checkQuestions(t *testing.T, category string, fields []schema.EvaluationField, expected []expectedQuestion) {
+	t.Helper()
+	if len(fields) != len(expected) {
+		t.Fatalf("%s: got %d questions, want %d", category, len(fields), len(expected))
+	}
+	for i, want := range expected {
+		field := fields[i]
+		if field.Description != want.description {
+			t.Errorf("%s question %d: got description %q, want %q", category, i, field.Description, want.description)
+		}
+		responses := 0
+		for j, response := range schema.LikertResponses {
+			if field.Counts[response] != want.counts[j] {
+				t.Errorf("%s question %d: got %d responses of %d, want %d", category, i, field.Counts[response], response, want.counts[j])
+			}
+			if field.Percentages[response] != want.percentages[j] {
+				t.Errorf("%s question %d: got %v%% of %d, want %v%%", category, i, field.Percentages[response], response, want.percentages[j])
+			}
+			responses += want.counts[j]
+		}
+		if field.Summary.Responses != responses {
+			t.Errorf("%s question %d: got %d responses in the summary, want %d", category, i, field.Summary.Responses, responses)
+		}
+	}
+}
+
+func TestParseComplete(t *testing.T) {
+	evaluation, err := parseSample(t, "report_complete.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The reported percentages are kept as they are
+	checkQuestions(t, "course experience", evaluation.CourseExperience, []expectedQuestion{
+		{"The course objectives were clearly stated.", [5]int{1, 2, 3, 12, 12}, [5]float32{3.33, 6.67, 10, 40, 40}},
+		{"The assignments helped me learn the course material.", [5]int{0, 1, 5, 14, 10}, [5]float32{0, 3.33, 16.67, 46.67, 33.33}},
+	})
+	checkQuestions(t, "instructor experience", evaluation.InstructorExperience, []expectedQuestion{
+		{"The instructor was well prepared for class.", [5]int{0, 0, 2, 10, 18}, [5]float32{0, 0, 6.67, 33.33, 60}},
+		{"The instructor was available to help outside of class.", [5]int{1, 1, 6, 11, 10}, [5]float32{3.45, 3.45, 20.69, 37.93, 34.48}},
+	})
+	checkQuestions(t, "student experience", evaluation.StudentExperience, []expectedQuestion{
+		{"I attended class regularly.", [5]int{0, 1, 1, 8, 20}, [5]float32{0, 3.33, 3.33, 26.67, 66.67}},
+	})
+}
+
+func TestParseCountsOnly(t *testing.T) {
+	evaluation, err := parseSample(t, "report_counts_only.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without reported percentages, they're computed from the counts, and a question nobody answered has none
+	checkQuestions(t, "course experience", evaluation.CourseExperience, []expectedQuestion{
+		{"The course was well organized.", [5]int{2, 3, 5, 6, 4}, [5]float32{10, 15, 25, 30, 20}},
+	})
+	checkQuestions(t, "instructor experience", evaluation.InstructorExperience, []expectedQuestion{
+		{"The instructor explained concepts clearly.", [5]int{1, 1, 3, 8, 7}, [5]float32{5, 5, 15, 40, 35}},
+		{"The instructor treated students with respect.", [5]int{0, 0, 0, 0, 0}, [5]float32{0, 0, 0, 0, 0}},
+	})
+	checkQuestions(t, "student experience", evaluation.StudentExperience, []expectedQuestion{
+		{"I completed the assigned readings.", [5]int{1, 2, 6, 9, 2}, [5]float32{5, 10, 30, 45, 10}},
+	})
+
+	if summary := evaluation.InstructorExperience[1].Summary; summary != (schema.EvaluationSummary{}) {
+		t.Errorf("got summary %+v for a question nobody answered, want an empty one", summary)
+	}
+}
+
+func TestParseUnavailable(t *testing.T) {
+	if _, err := parseSample(t, "report_unavailable.html"); !errors.Is(err, ues.ErrNoQuestions) {
+		t.Errorf("got error %v, want ErrNoQuestions", err)
+	}
+}
@@ -0,0 +1,60 @@
+// Package uestest serves UES reports in coursebook's place, so the scrape path can be run and tested without credentials or network access.
+// It holds hand-built sample reports of each layout the ues parser supports, and is only meant for tests and the uesstub command.
+package uestest
+
+import (
+	"embed"
+	"hash/fnv"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// The hand-built sample reports, which the stub server serves by default.
+//
+//go:embed testdata/*.html
+var sampleReports embed.FS
+
+// SampleReports returns the sample reports in testdata.
+func SampleReports() fs.FS {
+	reports, _ := fs.Sub(sampleReports, "testdata")
+	return reports
+}
+
+// NewStubHandler returns a handler that serves reports from the HTML files in reports at coursebook's /ues-report/{id} path, so the whole
+// scrape path can run locally without credentials or network access. A report named {id}.html is served for its own section, and every other
+// section gets one of the rest, picked by a hash of its id so the same section always gets the same report.
+func NewStubHandler(reports fs.FS) (http.Handler, error) {
+	names, err := fs.Glob(reports, "*.html")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ues-report/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/ues-report/")
+		if id == "" || strings.Contains(id, "/") || len(names) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		name := id + ".html"
+		if _, err := fs.Stat(reports, name); err != nil {
+			hash := fnv.New32a()
+			hash.Write([]byte(id))
+			name = names[hash.Sum32()%uint32(len(names))]
+		}
+
+		report, err := fs.ReadFile(reports, path.Clean(name))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(report)
+	})
+	return mux, nil
+}
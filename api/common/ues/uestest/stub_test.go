@@ -0,0 +1,109 @@
+package uestest
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/UTDNebula/nebula-api/api/common/ues"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// startStub serves reports from a stub server for the length of the test, returning a source fetching from it.
+func startStub(t *testing.T, reports fs.FS) *ues.Coursebook {
+	t.Helper()
+	handler, err := NewStubHandler(reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return ues.NewCoursebook(server.URL, nil, 0)
+}
+
+// stubSection returns a course and section whose report id is e.g. "CS1337.001.23F".
+func stubSection(prefix string, number string, sectionNumber string, session string) (schema.Course, schema.Section) {
+	course := schema.Course{Subject_prefix: prefix, Course_number: number}
+	section := schema.Section{Id: primitive.NewObjectID(), Section_number: sectionNumber}
+	section.Academic_session.Name = session
+	return course, section
+}
+
+func TestStubServesReportForItsOwnID(t *testing.T) {
+	report, err := fs.ReadFile(SampleReports(), "report_counts_only.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := fstest.MapFS{"CS1337.001.23F.html": &fstest.MapFile{Data: report}}
+	for _, name := range []string{"report_complete.html", "report_unavailable.html"} {
+		data, err := fs.ReadFile(SampleReports(), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reports[name] = &fstest.MapFile{Data: data}
+	}
+	source := startStub(t, reports)
+
+	course, section := stubSection("CS", "1337", "001", "23F")
+	evaluation, err := source.Fetch(context.Background(), course, section)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evaluation.Id != section.Id {
+		t.Errorf("got evaluation id %s, want the section's id %s", evaluation.Id.Hex(), section.Id.Hex())
+	}
+	if len(evaluation.CourseExperience) != 1 || evaluation.CourseExperience[0].Description != "The course was well organized." {
+		t.Errorf("got course experience %+v, want the single question of the report named after the section", evaluation.CourseExperience)
+	}
+}
+
+func TestStubMapsOtherIDsToTheSameReport(t *testing.T) {
+	source := startStub(t, SampleReports())
+	restarted := startStub(t, SampleReports())
+
+	sections := [][4]string{
+		{"CS", "1337", "001", "23F"},
+		{"CS", "2305", "002", "22S"},
+		{"MATH", "2417", "003", "21U"},
+		{"ECS", "1100", "0W1", "23S"},
+		{"HIST", "1301", "501", "20F"},
+		{"GOVT", "2305", "010", "22F"},
+		{"PHYS", "2325", "001", "23F"},
+		{"ACCT", "2301", "006", "19S"},
+	}
+
+	distinct := make(map[string]bool)
+	for _, fields := range sections {
+		course, section := stubSection(fields[0], fields[1], fields[2], fields[3])
+		id := ues.ReportID(course, section)
+
+		first, firstErr := source.Fetch(context.Background(), course, section)
+		again, againErr := source.Fetch(context.Background(), course, section)
+		other, otherErr := restarted.Fetch(context.Background(), course, section)
+
+		if firstErr != nil && !errors.Is(firstErr, ues.ErrNoQuestions) {
+			t.Fatalf("%s: %v", id, firstErr)
+		}
+		if !errors.Is(againErr, firstErr) || !errors.Is(otherErr, firstErr) {
+			t.Errorf("%s: got errors %v, %v and %v, want the same each time", id, firstErr, againErr, otherErr)
+		}
+		if !reflect.DeepEqual(first, again) || !reflect.DeepEqual(first, other) {
+			t.Errorf("%s: got different reports for the same section", id)
+		}
+
+		if errors.Is(firstErr, ues.ErrNoQuestions) {
+			distinct["unavailable"] = true
+		} else {
+			distinct[first.CourseExperience[0].Description] = true
+		}
+	}
+	if len(distinct) < 2 {
+		t.Errorf("every section got the same report, want them spread over the samples")
+	}
+}
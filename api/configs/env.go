@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/UTDNebula/nebula-api/api/common/log"
 
@@ -74,3 +75,221 @@ func GetEnvLimit() int64 {
 
 	return limit
 }
+
+// GetEnvAdminKey retrieves the API key that grants access to the administrative endpoints from the "ADMIN_API_KEY" environment variable.
+// It returns false when the variable is missing or empty, in which case the administrative endpoints are disabled.
+func GetEnvAdminKey() (string, bool) {
+
+	key, exist := os.LookupEnv("ADMIN_API_KEY")
+	if !exist || key == "" {
+		return "", false
+	}
+
+	return key, true
+}
+
+// GetEnvGradeRefreshInterval retrieves how often the precomputed grade aggregates are rebuilt from the "GRADE_REFRESH_INTERVAL" environment
+// variable, written as a duration such as "12h". If it is missing or invalid, it defaults to 24 hours.
+func GetEnvGradeRefreshInterval() time.Duration {
+
+	const defaultInterval = 24 * time.Hour
+
+	intervalString, exist := os.LookupEnv("GRADE_REFRESH_INTERVAL")
+	if !exist {
+		return defaultInterval // Return default if GRADE_REFRESH_INTERVAL is not set
+	}
+
+	interval, err := time.ParseDuration(intervalString)
+	if err != nil || interval <= 0 {
+		return defaultInterval // Return default if the value is not a valid duration
+	}
+
+	return interval
+}
+
+// GetEnvAutocompleteRefreshInterval retrieves how often the in-memory typeahead index and autocomplete DAG are rebuilt from the "AUTOCOMPLETE_REFRESH_INTERVAL"
+// environment variable, written as a duration such as "30m". If it is missing or invalid, it defaults to 1 hour.
+func GetEnvAutocompleteRefreshInterval() time.Duration {
+
+	const defaultInterval = time.Hour
+
+	intervalString, exist := os.LookupEnv("AUTOCOMPLETE_REFRESH_INTERVAL")
+	if !exist {
+		return defaultInterval // Return default if AUTOCOMPLETE_REFRESH_INTERVAL is not set
+	}
+
+	interval, err := time.ParseDuration(intervalString)
+	if err != nil || interval <= 0 {
+		return defaultInterval // Return default if the value is not a valid duration
+	}
+
+	return interval
+}
+
+// GetEnvEvaluationMaxAge retrieves how long an evaluation scraped from coursebook is served before it is scraped again from the "EVALUATION_MAX_AGE"
+// environment variable, written as a duration such as "720h". If it is missing or invalid, it defaults to 30 days.
+func GetEnvEvaluationMaxAge() time.Duration {
+
+	const defaultMaxAge = 30 * 24 * time.Hour
+
+	maxAgeString, exist := os.LookupEnv("EVALUATION_MAX_AGE")
+	if !exist {
+		return defaultMaxAge // Return default if EVALUATION_MAX_AGE is not set
+	}
+
+	maxAge, err := time.ParseDuration(maxAgeString)
+	if err != nil || maxAge <= 0 {
+		return defaultMaxAge // Return default if the value is not a valid duration
+	}
+
+	return maxAge
+}
+
+// GetEnvEvaluationRetryInterval retrieves how long a section found to have no evaluation report is remembered before coursebook is checked again
+// from the "EVALUATION_RETRY_INTERVAL" environment variable, written as a duration such as "24h". If it is missing or invalid, it defaults to 24 hours.
+func GetEnvEvaluationRetryInterval() time.Duration {
+
+	const defaultInterval = 24 * time.Hour
+
+	intervalString, exist := os.LookupEnv("EVALUATION_RETRY_INTERVAL")
+	if !exist {
+		return defaultInterval // Return default if EVALUATION_RETRY_INTERVAL is not set
+	}
+
+	interval, err := time.ParseDuration(intervalString)
+	if err != nil || interval <= 0 {
+		return defaultInterval // Return default if the value is not a valid duration
+	}
+
+	return interval
+}
+
+// GetEnvEvaluationWorkers retrieves how many evaluation scrape jobs run at once from the "EVALUATION_WORKERS" environment variable.
+// If it is missing or not a positive integer, it defaults to 2.
+func GetEnvEvaluationWorkers() int {
+
+	const defaultWorkers = 2
+
+	workersString, exist := os.LookupEnv("EVALUATION_WORKERS")
+	if !exist {
+		return defaultWorkers // Return default if EVALUATION_WORKERS is not set
+	}
+
+	workers, err := strconv.Atoi(workersString)
+	if err != nil || workers <= 0 {
+		return defaultWorkers // Return default if the value is not a valid worker count
+	}
+
+	return workers
+}
+
+// GetEnvScrapeRateLimit retrieves the minimum time between two scraping requests to the same host from the "SCRAPE_RATE_LIMIT" environment
+// variable, written as a duration such as "5s". If it is missing or invalid, it defaults to 5 seconds.
+func GetEnvScrapeRateLimit() time.Duration {
+
+	const defaultRateLimit = 5 * time.Second
+
+	rateLimitString, exist := os.LookupEnv("SCRAPE_RATE_LIMIT")
+	if !exist {
+		return defaultRateLimit // Return default if SCRAPE_RATE_LIMIT is not set
+	}
+
+	rateLimit, err := time.ParseDuration(rateLimitString)
+	if err != nil || rateLimit < 0 {
+		return defaultRateLimit // Return default if the value is not a valid duration
+	}
+
+	return rateLimit
+}
+
+// GetEnvCoursebookURL retrieves the base URL evaluations are scraped from from the "COURSEBOOK_URL" environment variable, such as the address of
+// a local stub server. If it is missing, it defaults to UTD's coursebook.
+func GetEnvCoursebookURL() string {
+
+	const defaultCoursebookURL = "https://coursebook.utdallas.edu"
+
+	coursebookURL, exist := os.LookupEnv("COURSEBOOK_URL")
+	if !exist || coursebookURL == "" {
+		return defaultCoursebookURL // Return default if COURSEBOOK_URL is not set
+	}
+
+	return coursebookURL
+}
+
+// GetEnvCoursebookLogin retrieves whether to log in before scraping, and the URL of the sign-on page to log in at, from the "COURSEBOOK_LOGIN"
+// and "COURSEBOOK_LOGIN_URL" environment variables. Logging in is disabled by setting COURSEBOOK_LOGIN to false, and the sign-on page defaults
+// to UTD's.
+func GetEnvCoursebookLogin() (loginURL string, enabled bool) {
+
+	const defaultLoginURL = "https://wat.utdallas.edu/login"
+
+	if enabledString, exist := os.LookupEnv("COURSEBOOK_LOGIN"); exist {
+		if enabled, err := strconv.ParseBool(enabledString); err == nil && !enabled {
+			return "", false
+		}
+	}
+
+	loginURL, exist := os.LookupEnv("COURSEBOOK_LOGIN_URL")
+	if !exist || loginURL == "" {
+		loginURL = defaultLoginURL // Use default if COURSEBOOK_LOGIN_URL is not set
+	}
+
+	return loginURL, true
+}
+
+// GetEnvStorageDir retrieves the directory the storage endpoints keep their buckets in from the "STORAGE_DIR" environment variable.
+// If it is missing, it defaults to "storage" in the working directory.
+func GetEnvStorageDir() string {
+
+	dir, exist := os.LookupEnv("STORAGE_DIR")
+	if !exist || dir == "" {
+		return "storage" // Return default if STORAGE_DIR is not set
+	}
+
+	return dir
+}
+
+// GetEnvStorageMaxObjectSize retrieves the largest object the storage endpoints accept, in bytes, from the "STORAGE_MAX_OBJECT_SIZE" environment
+// variable. If it is missing or not a positive integer, it defaults to 10 MiB.
+func GetEnvStorageMaxObjectSize() int64 {
+
+	const defaultMaxSize int64 = 10 << 20
+
+	maxSizeString, exist := os.LookupEnv("STORAGE_MAX_OBJECT_SIZE")
+	if !exist {
+		return defaultMaxSize // Return default if STORAGE_MAX_OBJECT_SIZE is not set
+	}
+
+	maxSize, err := strconv.ParseInt(maxSizeString, 10, 64)
+	if err != nil || maxSize <= 0 {
+		return defaultMaxSize // Return default if the value is not a valid size
+	}
+
+	return maxSize
+}
+
+// GetEnvAPIKeyStore retrieves where API keys are kept from the "API_KEY_STORE" environment variable: "mongo" for the api_keys collection, or
+// "file" for the JSON file named by "API_KEY_FILE", which defaults to "api_keys.json" in the working directory. It returns an empty kind when
+// the variable is missing, in which case API keys aren't checked. An unknown kind logs an error and terminates, rather than leave the API open.
+func GetEnvAPIKeyStore() (kind string, path string) {
+
+	kind, exist := os.LookupEnv("API_KEY_STORE")
+	if !exist || kind == "" {
+		return "", ""
+	}
+
+	switch kind {
+	case "mongo":
+		return kind, ""
+	case "file":
+		path, exist = os.LookupEnv("API_KEY_FILE")
+		if !exist || path == "" {
+			path = "api_keys.json" // Use default if API_KEY_FILE is not set
+		}
+		return kind, path
+	}
+
+	log.WriteErrorMsg("Error loading 'API_KEY_STORE' from the .env file: expected mongo or file")
+	os.Exit(1) // Exit the program rather than serve without checking keys
+	return "", ""
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/UTDNebula/nebula-api/api/common/apikey"
 	"github.com/UTDNebula/nebula-api/api/common/log"
 	"github.com/gin-gonic/gin"
 
@@ -87,6 +88,19 @@ func GetCollection(collectionName string) *mongo.Collection {
 	return collection
 }
 
+// GetAPIKeyStore returns the store API keys are checked against, as configured by GetEnvAPIKeyStore. It returns false when no store is
+// configured.
+func GetAPIKeyStore() (apikey.Store, bool) {
+	kind, path := GetEnvAPIKeyStore()
+	switch kind {
+	case "mongo":
+		return apikey.NewMongoStore(GetCollection("api_keys")), true
+	case "file":
+		return apikey.NewFileStore(path), true
+	}
+	return nil, false
+}
+
 // GetOptionLimit generates a MongoDB FindOptions object with a limit and offset for paginated queries.
 // It retrieves the 'offset' query parameter from the request context and applies it along with a limit
 // from the environment variables or a default value.
@@ -0,0 +1,129 @@
+// Package controllers handles the business logic of the API, including the administrative endpoints, which are only available to clients
+// presenting an API key with the admin scope.
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/responses"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GradeDataQuality lists the sections whose grade_distribution array is quarantined from every aggregate, along with the reason. Arrays are only
+// validated, never remapped, so an array that isn't in the canonical layout or has a negative count is quarantined.
+//
+// @Id gradeDataQuality
+// @Router /admin/data-quality/grades [get]
+// @Description "Returns the sections whose grade distributions are malformed and quarantined"
+// @Produce json
+// @Security apiKey
+// @Param status query string false "Only include issues with this status, which is always quarantined"
+// @Param offset query integer false "The starting position of the current page of issues (e.g. For starting at the 17th issue, offset=16)."
+// @Success 200 {array} schema.GradeQualityIssue "A list of sections with grade distribution issues"
+func GradeDataQuality(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var issues []schema.GradeQualityIssue
+
+	offset, ok := offsetQuery(c)
+	if !ok {
+		return
+	}
+
+	issueMatch := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "length", Value: bson.D{{Key: "$ne", Value: len(schema.GradeLabels)}}}},
+		bson.D{{Key: "negative", Value: true}},
+	}}}
+	if status := c.Query("status"); status != "" && status != schema.GRADE_QUARANTINED {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: "Invalid status parameter."})
+		return
+	}
+
+	// Find the sections with grades whose array isn't exactly the canonical buckets
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "grade_distribution.0", Value: bson.D{{Key: "$exists", Value: true}}}}}},
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "course_reference", Value: 1},
+			{Key: "section_number", Value: 1},
+			{Key: "academic_session", Value: "$academic_session.name"},
+			{Key: "grade_distribution", Value: 1},
+			{Key: "length", Value: bson.D{{Key: "$size", Value: "$grade_distribution"}}},
+			{Key: "negative", Value: bson.D{{Key: "$anyElementTrue", Value: bson.A{bson.D{{Key: "$map", Value: bson.D{
+				{Key: "input", Value: "$grade_distribution"},
+				{Key: "in", Value: bson.D{{Key: "$lt", Value: bson.A{"$$this", 0}}}},
+			}}}}}}},
+		}}},
+		bson.D{{Key: "$match", Value: issueMatch}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+		bson.D{{Key: "$skip", Value: offset}},
+		bson.D{{Key: "$limit", Value: configs.GetEnvLimit()}},
+	}
+
+	cursor, err := sectionCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// Retrieve and parse all valid documents
+	if err = cursor.All(ctx, &issues); err != nil {
+		log.WritePanic(err)
+		panic(err)
+	}
+
+	// Give the reason each issue is quarantined, the same way the aggregations treat it
+	for i := range issues {
+		issues[i].Length = len(issues[i].Grade_distribution)
+		issues[i].Status = schema.GRADE_QUARANTINED
+		if _, err := schema.ParseGradeDistribution(issues[i].Grade_distribution); err != nil {
+			issues[i].Reason = err.Error()
+		}
+	}
+
+	c.JSON(http.StatusOK, responses.GradeQualityResponse{Status: http.StatusOK, Message: "success", Data: issues})
+}
+
+// RefreshGradeAggregates starts rebuilding the precomputed grade aggregates in the background.
+//
+// @Id refreshGradeAggregates
+// @Router /admin/grade-aggregates/refresh [post]
+// @Description "Starts rebuilding the precomputed grade aggregates"
+// @Produce json
+// @Security apiKey
+// @Success 202 {object} responses.ErrorResponse "The rebuild has started"
+// @Failure 409 {object} responses.ErrorResponse "A rebuild is already running"
+func RefreshGradeAggregates(c *gin.Context) {
+	if !startGradeAggregateRefresh() {
+		c.JSON(http.StatusConflict, responses.ErrorResponse{Status: http.StatusConflict, Message: "error", Data: "A refresh is already running."})
+		return
+	}
+	c.JSON(http.StatusAccepted, responses.ErrorResponse{Status: http.StatusAccepted, Message: "success", Data: "Refresh started."})
+}
+
+// RefreshAutocomplete rebuilds the typeahead index and the autocomplete DAG right away, instead of waiting for the next scheduled rebuild.
+//
+// @Id refreshAutocomplete
+// @Router /admin/autocomplete/refresh [post]
+// @Description "Rebuilds the typeahead index and the autocomplete DAG"
+// @Produce json
+// @Security apiKey
+// @Success 200 {object} responses.ErrorResponse "The rebuild has finished"
+func RefreshAutocomplete(c *gin.Context) {
+	if err := rebuildAutocomplete(); err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, responses.ErrorResponse{Status: http.StatusOK, Message: "success", Data: "Refresh finished."})
+}
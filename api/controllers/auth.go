@@ -0,0 +1,178 @@
+// Package controllers handles the business logic of the API, including checking the API key each request presents in its x-api-key header.
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/apikey"
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/responses"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Keys are checked in two ways:
+//
+//	Key store:      When API_KEY_STORE is set, every request must present a current key from the store, and each endpoint requires a scope.
+//	                Without a store, reading is open to everyone, as when the API is behind a gateway that checks keys itself.
+//	ADMIN_API_KEY:  The admin key from the environment is always accepted as a key with the admin scope, so a deployment can be
+//	                administered before any key is issued.
+//
+// Writing and administration always need a key with the right scope, so they are disabled when neither is configured.
+
+// How long a key looked up from the store is reused, which is also how long revoking a key can take to apply.
+const apiKeyCacheTTL = time.Minute
+
+// The context key of the API key a request was authenticated with.
+const apiKeyContextKey = "apiKey"
+
+// The store keys are checked against, if one is configured.
+var apiKeyStore, apiKeyStoreEnabled = configs.GetAPIKeyStore()
+
+// cachedAPIKey is a key looked up from the store.
+type cachedAPIKey struct {
+	key     schema.APIKey
+	fetched time.Time
+}
+
+// Recently looked up keys by id. Only keys that exist are kept, so the cache can't grow past the number of issued keys.
+var (
+	apiKeyCache      = make(map[string]cachedAPIKey)
+	apiKeyCacheMutex sync.Mutex
+)
+
+// Authenticate checks the API key of every request when a key store is configured, aborting requests without a current key with 401. The
+// swagger documentation and CORS preflight requests don't need a key. Without a key store, requests pass through unchecked.
+func Authenticate(c *gin.Context) {
+	if !apiKeyStoreEnabled || c.Request.Method == http.MethodOptions || strings.HasPrefix(c.Request.URL.Path, "/swagger/") {
+		c.Next()
+		return
+	}
+	if !authorizeScope(c, schema.API_KEY_SCOPE_READ) {
+		return
+	}
+	c.Next()
+}
+
+// RequireWrite aborts requests whose API key doesn't have the write scope.
+func RequireWrite(c *gin.Context) {
+	if !authorizeScope(c, schema.API_KEY_SCOPE_WRITE) {
+		return
+	}
+	c.Next()
+}
+
+// RequireAdmin aborts requests whose API key doesn't have the admin scope.
+func RequireAdmin(c *gin.Context) {
+	if !authorizeScope(c, schema.API_KEY_SCOPE_ADMIN) {
+		return
+	}
+	c.Next()
+}
+
+// authorizeScope checks that the request's API key has the given scope, aborting the request and returning false if it doesn't. It lets
+// endpoints guard options that need more than reading.
+//
+// Requests are refused with 403 when no key could have the scope, or the key doesn't have it, and with 401 when the key is missing or isn't
+// current.
+func authorizeScope(c *gin.Context, scope string) bool {
+	key, err := requestAPIKey(c)
+	switch {
+	case errors.Is(err, errNoKeySource):
+		c.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorResponse{Status: http.StatusForbidden, Message: "error", Data: "API keys are disabled, so this endpoint is too."})
+		return false
+	case errors.Is(err, errMissingKey):
+		c.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{Status: http.StatusUnauthorized, Message: "error", Data: "Missing API key."})
+		return false
+	case errors.Is(err, apikey.ErrInvalidKey), errors.Is(err, apikey.ErrExpired), errors.Is(err, apikey.ErrRevoked):
+		c.AbortWithStatusJSON(http.StatusUnauthorized, responses.ErrorResponse{Status: http.StatusUnauthorized, Message: "error", Data: err.Error()})
+		return false
+	case err != nil:
+		log.WriteError(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return false
+	}
+
+	if !key.HasScope(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, responses.ErrorResponse{Status: http.StatusForbidden, Message: "error", Data: "The API key doesn't have the " + scope + " scope."})
+		return false
+	}
+	return true
+}
+
+var (
+	errNoKeySource = errors.New("no API key store or admin API key is configured")
+	errMissingKey  = errors.New("missing API key")
+)
+
+// requestAPIKey returns the API key the request presents, verifying it the first time it's asked for.
+func requestAPIKey(c *gin.Context) (schema.APIKey, error) {
+	if value, ok := c.Get(apiKeyContextKey); ok {
+		return value.(schema.APIKey), nil
+	}
+
+	adminKey, adminKeyEnabled := configs.GetEnvAdminKey()
+	if !apiKeyStoreEnabled && !adminKeyEnabled {
+		return schema.APIKey{}, errNoKeySource
+	}
+
+	token := c.GetHeader("x-api-key")
+	if token == "" {
+		return schema.APIKey{}, errMissingKey
+	}
+
+	var key schema.APIKey
+	if adminKeyEnabled && subtle.ConstantTimeCompare([]byte(token), []byte(adminKey)) == 1 {
+		key = schema.APIKey{Id: "ADMIN_API_KEY", Name: "ADMIN_API_KEY", Scopes: []string{schema.API_KEY_SCOPE_ADMIN}}
+	} else if !apiKeyStoreEnabled {
+		return schema.APIKey{}, apikey.ErrInvalidKey
+	} else {
+		var err error
+		if key, err = verifyAPIKey(token); err != nil {
+			return schema.APIKey{}, err
+		}
+	}
+
+	c.Set(apiKeyContextKey, key)
+	return key, nil
+}
+
+// verifyAPIKey checks a key against the store, reusing recent lookups so that every request doesn't need one.
+func verifyAPIKey(token string) (schema.APIKey, error) {
+	id, secret, err := apikey.Parse(token)
+	if err != nil {
+		return schema.APIKey{}, err
+	}
+
+	apiKeyCacheMutex.Lock()
+	cached, ok := apiKeyCache[id]
+	apiKeyCacheMutex.Unlock()
+
+	if !ok || time.Since(cached.fetched) > apiKeyCacheTTL {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		key, err := apiKeyStore.Get(ctx, id)
+		if errors.Is(err, apikey.ErrNotFound) {
+			return schema.APIKey{}, apikey.ErrInvalidKey
+		}
+		if err != nil {
+			return schema.APIKey{}, err
+		}
+		cached = cachedAPIKey{key: key, fetched: time.Now()}
+
+		apiKeyCacheMutex.Lock()
+		apiKeyCache[id] = cached
+		apiKeyCacheMutex.Unlock()
+	}
+
+	return cached.key, apikey.Check(cached.key, secret, time.Now())
+}
@@ -1,13 +1,23 @@
 // Package controllers handles the business logic of the API, including the AutocompleteDAG function, which executes an aggregation pipeline query against
-// MongoDB to provide autocomplete suggestions for course-related data.
+// MongoDB to provide autocomplete suggestions for course-related data, and the Autocomplete function, which searches an in-memory index of courses
+// and professors as the user types.
 package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/UTDNebula/nebula-api/api/common/log"
 	"github.com/UTDNebula/nebula-api/api/responses"
+	"github.com/UTDNebula/nebula-api/api/schema"
 
 	"github.com/gin-gonic/gin"
 
@@ -16,20 +26,87 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// AutocompleteDAG fetches autocomplete suggestions for courses based on the aggregation pipeline. It retrieves related sections and professors
-// by performing several MongoDB lookups and unwinds, before grouping the results into course numbers and subject prefixes.
-// This method also handles HTTP GET requests and returns a JSON response containing  the relevant course and professor data.
-//
-// Parameters:
-//   - c: *gin.Context - Gin's context to handle the request, response, and parameters.
+// How long browsers may reuse the autocomplete DAG before checking whether it has changed.
+const autocompleteDAGMaxAge = 5 * time.Minute
+
+// autocompleteDAG is the computed DAG, with an ETag identifying its contents.
+type autocompleteDAG struct {
+	prefixes []schema.DAGPrefix
+	etag     string
+}
+
+// The current DAG, replaced as a whole on each rebuild.
+var currentAutocompleteDAG atomic.Pointer[autocompleteDAG]
+
+// AutocompleteDAG returns the tree of every subject prefix, course number, academic session, section and professor on record, or the subtree of
+// one subject prefix and/or academic session. The DAG is computed ahead of time and rebuilt on the same interval as the typeahead index, and
+// responses carry an ETag so clients can revalidate their copy instead of downloading it again.
 //
-// Returns:
-//   - JSON response with autocomplete results for courses and professors.
+// @Id autocompleteDAG
+// @Router /autocomplete/dag [get]
+// @Description "Returns the subject prefix, course number, academic session, section and professor tree used for autocompletion"
+// @Produce json
+// @Param prefix query string false "Only include this subject prefix"
+// @Param term query string false "Only include this academic session, e.g. 23F"
+// @Param If-None-Match header string false "The ETag of a previously returned DAG"
+// @Success 200 {array} schema.DAGPrefix "The DAG"
+// @Success 304 "The DAG hasn't changed"
 func AutocompleteDAG(c *gin.Context) {
+	prefix, term := strings.ToUpper(c.Query("prefix")), strings.ToUpper(c.Query("term"))
+	if term != "" {
+		if _, err := schema.ParseTerm(term); err != nil {
+			c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+			return
+		}
+	}
+
+	dag, err := getAutocompleteDAG()
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.AutocompleteResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// Each subtree gets its own ETag, derived from the DAG's so it changes whenever the DAG does
+	etag := dag.etag
+	if prefix != "" || term != "" {
+		etag = contentETag([]byte(dag.etag + "/" + prefix + "/" + term))
+	}
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(autocompleteDAGMaxAge.Seconds())))
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	// Return the response with the aggregation results
+	c.JSON(http.StatusOK, responses.AutocompleteResponse{Status: http.StatusOK, Message: "success", Data: filterAutocompleteDAG(dag.prefixes, prefix, term)})
+}
+
+// getAutocompleteDAG returns the current DAG, building it first if it hasn't been built yet.
+func getAutocompleteDAG() (*autocompleteDAG, error) {
+	if dag := currentAutocompleteDAG.Load(); dag != nil {
+		return dag, nil
+	}
+
+	autocompleteBuildMutex.Lock()
+	defer autocompleteBuildMutex.Unlock()
+	if dag := currentAutocompleteDAG.Load(); dag != nil {
+		return dag, nil
+	}
+	if err := buildAutocompleteDAG(); err != nil {
+		return nil, err
+	}
+	return currentAutocompleteDAG.Load(), nil
+}
+
+// buildAutocompleteDAG computes the DAG from the database by joining every course's sections and their professors, before grouping the results into
+// course numbers and subject prefixes.
+func buildAutocompleteDAG() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	var autocompleteDAG []map[string]interface{}
+	var prefixes []schema.DAGPrefix
 
 	// This program defines the aggregation pipeline
 	autocompletePipeline := mongo.Pipeline{
@@ -193,15 +270,152 @@ func AutocompleteDAG(c *gin.Context) {
 	// Gets cursor for aggregation pipeline query results and execute the aggregation against the courses collection
 	cursor, err := courseCollection.Aggregate(ctx, autocompletePipeline)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, responses.AutocompleteResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
-		return
+		return err
 	}
 
 	// retrieve and parse all valid documents from the cursor
-	if err = cursor.All(ctx, &autocompleteDAG); err != nil {
-		panic(err)
+	if err = cursor.All(ctx, &prefixes); err != nil {
+		return err
 	}
 
-	// Return the response with the aggregation results
-	c.JSON(http.StatusOK, responses.AutocompleteResponse{Status: http.StatusOK, Message: "success", Data: autocompleteDAG})
+	// Order the tree so the same data always produces the same ETag
+	sort.Slice(prefixes, func(i, j int) bool { return prefixes[i].Subject_prefix < prefixes[j].Subject_prefix })
+	for _, prefix := range prefixes {
+		sort.Slice(prefix.Course_numbers, func(i, j int) bool {
+			return prefix.Course_numbers[i].Course_number < prefix.Course_numbers[j].Course_number
+		})
+		for _, course := range prefix.Course_numbers {
+			sort.SliceStable(course.Academic_sessions, func(i, j int) bool {
+				a, errA := schema.ParseTerm(dagSessionName(course.Academic_sessions[i]))
+				b, errB := schema.ParseTerm(dagSessionName(course.Academic_sessions[j]))
+				if errA != nil || errB != nil {
+					return errA == nil && errB != nil
+				}
+				return a.Before(b)
+			})
+			for _, session := range course.Academic_sessions {
+				sort.Slice(session.Sections, func(i, j int) bool {
+					return session.Sections[i].Section_number < session.Sections[j].Section_number
+				})
+			}
+		}
+	}
+
+	body, err := json.Marshal(prefixes)
+	if err != nil {
+		return err
+	}
+	currentAutocompleteDAG.Store(&autocompleteDAG{prefixes: prefixes, etag: contentETag(body)})
+	return nil
+}
+
+// filterAutocompleteDAG returns the subtree of the DAG under a subject prefix and/or academic session, leaving out the course numbers and
+// subject prefixes left without any academic sessions. Either filter is ignored when empty.
+func filterAutocompleteDAG(prefixes []schema.DAGPrefix, prefix string, term string) []schema.DAGPrefix {
+	if prefix == "" && term == "" {
+		return prefixes
+	}
+
+	filtered := []schema.DAGPrefix{}
+	for _, dagPrefix := range prefixes {
+		if prefix != "" && !strings.EqualFold(dagPrefix.Subject_prefix, prefix) {
+			continue
+		}
+		if term == "" {
+			filtered = append(filtered, dagPrefix)
+			continue
+		}
+
+		var courses []schema.DAGCourse
+		for _, course := range dagPrefix.Course_numbers {
+			var sessions []schema.DAGSession
+			for _, session := range course.Academic_sessions {
+				if dagSessionName(session) == term {
+					sessions = append(sessions, session)
+				}
+			}
+			if len(sessions) > 0 {
+				courses = append(courses, schema.DAGCourse{Course_number: course.Course_number, Academic_sessions: sessions})
+			}
+		}
+		if len(courses) > 0 {
+			filtered = append(filtered, schema.DAGPrefix{Subject_prefix: dagPrefix.Subject_prefix, Course_numbers: courses})
+		}
+	}
+	return filtered
+}
+
+// dagSessionName returns the name of an academic session in the DAG, which is empty for courses without sections.
+func dagSessionName(session schema.DAGSession) string {
+	if session.Academic_session == nil {
+		return ""
+	}
+	return session.Academic_session.Name
+}
+
+// contentETag returns a strong ETag for the given content.
+func contentETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists the given ETag, comparing weakly as the header requires.
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// The most suggestions a single typeahead search can return.
+const maxAutocompleteLimit = 50
+
+// Autocomplete returns the courses and professors best matching a partially typed query, ranked by how well they match. Courses match on their
+// subject prefix, number and the words of their title, and professors on their names with some tolerance for typos.
+//
+// @Id autocomplete
+// @Router /autocomplete [get]
+// @Description "Returns the courses and professors best matching a partially typed query"
+// @Produce json
+// @Param q query string true "The query, e.g. cs 13 or computer sci"
+// @Param types query string false "A comma separated list of the types of result to include: course, professor. Defaults to both"
+// @Param limit query integer false "The most results to return, up to 50. Defaults to 10"
+// @Success 200 {array} schema.Suggestion "The best matching courses and professors"
+func Autocomplete(c *gin.Context) {
+	query := c.Query("q")
+
+	types := map[string]bool{schema.SUGGESTION_COURSE: true, schema.SUGGESTION_PROFESSOR: true}
+	if typesString := c.Query("types"); typesString != "" {
+		types = make(map[string]bool)
+		for _, suggestionType := range strings.Split(typesString, ",") {
+			suggestionType = strings.TrimSpace(suggestionType)
+			if suggestionType != schema.SUGGESTION_COURSE && suggestionType != schema.SUGGESTION_PROFESSOR {
+				c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: "Invalid types parameter."})
+				return
+			}
+			types[suggestionType] = true
+		}
+	}
+
+	limit := 10
+	if limitString := c.Query("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxAutocompleteLimit {
+			c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: "Invalid limit parameter."})
+			return
+		}
+	}
+
+	index, err := getAutocompleteIndex()
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, responses.AutocompleteResponse{Status: http.StatusOK, Message: "success", Data: index.search(query, types, limit)})
 }
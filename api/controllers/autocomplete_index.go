@@ -0,0 +1,317 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The typeahead search scores every course and professor against the words of the query, and only returns those matching every word.
+// How well each word matches adds to the score:
+//
+//	Course:     subject prefix or number (exact, then starts with) > a word of the title (exact, then starts with)
+//	Professor:  a name (exact, then starts with) > a name within a small edit distance of the word
+//
+// The index is rebuilt from the database on an interval, so searches never touch the database.
+
+// Scores for each way a word of the query can match.
+const (
+	scoreExactCode   = 100.0
+	scorePrefixCode  = 70.0
+	scoreExactTitle  = 50.0
+	scorePrefixTitle = 35.0
+	scoreExactName   = 80.0
+	scorePrefixName  = 60.0
+	scoreFuzzyName   = 30.0
+)
+
+// autocompleteIndex is a snapshot of the courses and professors to search, with their fields broken into lowercase words.
+type autocompleteIndex struct {
+	courses    []indexedCourse
+	professors []indexedProfessor
+}
+
+type indexedCourse struct {
+	suggestion schema.Suggestion
+	prefix     string
+	number     string
+	titleWords []string
+}
+
+type indexedProfessor struct {
+	suggestion schema.Suggestion
+	names      []string
+}
+
+// The current index, replaced as a whole on each rebuild so searches never see a partial one.
+var currentAutocompleteIndex atomic.Pointer[autocompleteIndex]
+
+// autocompleteBuildMutex makes concurrent requests wait for the same first build instead of each building the index or DAG.
+var autocompleteBuildMutex sync.Mutex
+
+// StartAutocomplete builds the typeahead index and the autocomplete DAG, and rebuilds them on every interval for as long as the server runs.
+func StartAutocomplete() {
+	go func() {
+		if err := rebuildAutocomplete(); err != nil {
+			log.WriteError(err)
+		}
+		for range time.Tick(configs.GetEnvAutocompleteRefreshInterval()) {
+			if err := rebuildAutocomplete(); err != nil {
+				log.WriteError(err)
+			}
+		}
+	}()
+}
+
+// getAutocompleteIndex returns the current index, building it first if it hasn't been built yet.
+func getAutocompleteIndex() (*autocompleteIndex, error) {
+	if index := currentAutocompleteIndex.Load(); index != nil {
+		return index, nil
+	}
+
+	autocompleteBuildMutex.Lock()
+	defer autocompleteBuildMutex.Unlock()
+	if index := currentAutocompleteIndex.Load(); index != nil {
+		return index, nil
+	}
+	if err := buildAutocompleteIndex(); err != nil {
+		return nil, err
+	}
+	return currentAutocompleteIndex.Load(), nil
+}
+
+// rebuildAutocomplete replaces the typeahead index and the autocomplete DAG with fresh ones from the database.
+func rebuildAutocomplete() error {
+	autocompleteBuildMutex.Lock()
+	defer autocompleteBuildMutex.Unlock()
+	if err := buildAutocompleteIndex(); err != nil {
+		return err
+	}
+	return buildAutocompleteDAG()
+}
+
+// buildAutocompleteIndex reads every course and professor from the database into a new index. Courses are listed once per subject prefix and
+// number, using their latest catalog year.
+func buildAutocompleteIndex() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var courses []schema.Course
+	var professors []schema.Professor
+
+	cursor, err := courseCollection.Find(ctx, bson.M{}, options.Find().
+		SetProjection(bson.M{"subject_prefix": 1, "course_number": 1, "title": 1, "catalog_year": 1}).
+		SetSort(bson.D{{Key: "catalog_year", Value: -1}}))
+	if err != nil {
+		return err
+	}
+	if err = cursor.All(ctx, &courses); err != nil {
+		return err
+	}
+
+	cursor, err = professorCollection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"first_name": 1, "last_name": 1}))
+	if err != nil {
+		return err
+	}
+	if err = cursor.All(ctx, &professors); err != nil {
+		return err
+	}
+
+	index := &autocompleteIndex{}
+	seen := make(map[string]bool)
+	for _, course := range courses {
+		key := course.Subject_prefix + " " + course.Course_number
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		index.courses = append(index.courses, indexedCourse{
+			suggestion: schema.Suggestion{
+				Type:           schema.SUGGESTION_COURSE,
+				Id:             course.Id,
+				Label:          strings.TrimSpace(key + " " + course.Title),
+				Subject_prefix: course.Subject_prefix,
+				Course_number:  course.Course_number,
+				Title:          course.Title,
+			},
+			prefix:     strings.ToLower(course.Subject_prefix),
+			number:     strings.ToLower(course.Course_number),
+			titleWords: searchWords(course.Title),
+		})
+	}
+	for _, professor := range professors {
+		index.professors = append(index.professors, indexedProfessor{
+			suggestion: schema.Suggestion{
+				Type:       schema.SUGGESTION_PROFESSOR,
+				Id:         professor.Id,
+				Label:      strings.TrimSpace(professor.First_name + " " + professor.Last_name),
+				First_name: professor.First_name,
+				Last_name:  professor.Last_name,
+			},
+			names: searchWords(professor.First_name + " " + professor.Last_name),
+		})
+	}
+
+	currentAutocompleteIndex.Store(index)
+	return nil
+}
+
+// search returns up to limit of the best suggestions of the given types for a query.
+func (index *autocompleteIndex) search(query string, types map[string]bool, limit int) []schema.Suggestion {
+	words := searchWords(query)
+	suggestions := []schema.Suggestion{}
+	if len(words) == 0 {
+		return suggestions
+	}
+
+	if types[schema.SUGGESTION_COURSE] {
+		for _, course := range index.courses {
+			if score, ok := course.score(words); ok {
+				suggestion := course.suggestion
+				suggestion.Score = score
+				suggestions = append(suggestions, suggestion)
+			}
+		}
+	}
+	if types[schema.SUGGESTION_PROFESSOR] {
+		for _, professor := range index.professors {
+			if score, ok := professor.score(words); ok {
+				suggestion := professor.suggestion
+				suggestion.Score = score
+				suggestions = append(suggestions, suggestion)
+			}
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Label < suggestions[j].Label
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// score adds up how well each word of the query matches the course, failing if any word doesn't match at all.
+func (course indexedCourse) score(words []string) (float64, bool) {
+	total := 0.0
+	for _, word := range words {
+		best := 0.0
+		switch {
+		case word == course.prefix || word == course.number:
+			best = scoreExactCode
+		case strings.HasPrefix(course.prefix, word) || strings.HasPrefix(course.number, word):
+			best = scorePrefixCode
+		}
+		for _, titleWord := range course.titleWords {
+			if titleWord == word {
+				best = max(best, scoreExactTitle)
+			} else if strings.HasPrefix(titleWord, word) {
+				best = max(best, scorePrefixTitle)
+			}
+		}
+		if best == 0 {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// score adds up how well each word of the query matches the professor's names, failing if any word doesn't match at all. Words of four or more
+// letters may be misspelled by one letter, and words of seven or more by two.
+func (professor indexedProfessor) score(words []string) (float64, bool) {
+	total := 0.0
+	for _, word := range words {
+		best := 0.0
+		for _, name := range professor.names {
+			switch {
+			case name == word:
+				best = max(best, scoreExactName)
+			case strings.HasPrefix(name, word):
+				best = max(best, scorePrefixName)
+			default:
+				tolerance := 0
+				if length := len([]rune(word)); length >= 7 {
+					tolerance = 2
+				} else if length >= 4 {
+					tolerance = 1
+				}
+				// Compare against the start of the name too, since the rest may not have been typed yet
+				distance := editDistance(word, name)
+				if nameRunes, wordRunes := []rune(name), []rune(word); len(nameRunes) > len(wordRunes) {
+					distance = min(distance, editDistance(word, string(nameRunes[:len(wordRunes)])))
+				}
+				if distance <= tolerance {
+					best = max(best, scoreFuzzyName-10*float64(distance))
+				}
+			}
+		}
+		if best == 0 {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// searchWords lowercases text and splits it into words, also splitting letters from digits so "cs1337" searches like "cs 1337".
+func searchWords(text string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case len(word) > 0 && unicode.IsDigit(r) != unicode.IsDigit(word[len(word)-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	return words
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
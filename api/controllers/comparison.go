@@ -0,0 +1,207 @@
+// Package controllers handles the business logic of the API, including the professor comparison for a course, which summarizes the grades and
+// evaluations of every professor who has taught it.
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/responses"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CourseProfessors groups the sections of a course by professor and returns, for each professor, the sections and terms they taught along with
+// the combined grades and evaluations of those sections. Sections from every catalog year of the course are considered.
+//
+// @Id courseProfessors
+// @Router /course/{id}/professors [get]
+// @Description "Returns the grades and evaluations of every professor who has taught the course with given ID"
+// @Produce json
+// @Param id path string true "ID of the course to compare professors for"
+// @Param term query string false "Only include this academic session, e.g. 23F"
+// @Param from query string false "Only include academic sessions from this one onwards, e.g. 21F"
+// @Param to query string false "Only include academic sessions up to and including this one, e.g. 23S"
+// @Param exclude_summer query boolean false "Leave out summer academic sessions"
+// @Param sort query string false "Sort by a grade statistic (mean_gpa, percent_a, pass_rate, dfw_rate, total_students, sample_count) or by name"
+// @Param order query string false "asc or desc, defaults to desc for statistics and asc for name"
+// @Success 200 {array} schema.CourseProfessor "A list of professors"
+func CourseProfessors(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	courseId := c.Param("id")
+	sortBy := c.DefaultQuery("sort", "sample_count")
+
+	var course schema.Course
+	var results []struct {
+		schema.CourseProfessor `bson:",inline"`
+		Grade_distributions    [][]int `bson:"grade_distributions"`
+	}
+	var evaluations []schema.Evaluation
+
+	// Parse object id from id parameter
+	objId, err := primitive.ObjectIDFromHex(courseId)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// Validate the sort and order parameters before touching the database
+	if _, ok := (schema.GradeStats{}).Metric(sortBy); !ok && sortBy != "name" {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: "Invalid sort parameter."})
+		return
+	}
+	descending := sortBy != "name"
+	switch c.Query("order") {
+	case "":
+	case "asc":
+		descending = false
+	case "desc":
+		descending = true
+	default:
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: "Invalid order parameter."})
+		return
+	}
+
+	var filters schema.GradeFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+	sessionFilter, err := gradeSessionFilter(filters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// Find and parse matching course
+	err = courseCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&course)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	courseIDs, err := catalogCourseIDs(ctx, course)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	sectionMatch := bson.D{{Key: "course_reference", Value: bson.D{{Key: "$in", Value: courseIDs}}}}
+	if sessionFilter != nil {
+		sectionMatch = append(sectionMatch, bson.E{Key: "academic_session.name", Value: sessionFilter})
+	}
+
+	// Group the course's sections by professor, then join the professor's name
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: sectionMatch}},
+		bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$professors"}}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$professors"},
+			{Key: "sections", Value: bson.D{{Key: "$push", Value: "$_id"}}},
+			{Key: "terms", Value: bson.D{{Key: "$addToSet", Value: "$academic_session.name"}}},
+			{Key: "grade_distributions", Value: bson.D{{Key: "$push", Value: "$grade_distribution"}}},
+		}}},
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "professors"},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "professor"},
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$professor"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}}},
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "first_name", Value: "$professor.first_name"},
+			{Key: "last_name", Value: "$professor.last_name"},
+			{Key: "sections", Value: 1},
+			{Key: "terms", Value: 1},
+			{Key: "grade_distributions", Value: 1},
+		}}},
+	}
+
+	cursor, err := sectionCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// Retrieve and parse all valid documents
+	if err = cursor.All(ctx, &results); err != nil {
+		log.WritePanic(err)
+		panic(err)
+	}
+
+	// Find whatever evaluations are on record for the sections
+	var sectionIDs []primitive.ObjectID
+	for _, result := range results {
+		sectionIDs = append(sectionIDs, result.Sections...)
+	}
+	cursor, err = evaluationCollection.Find(ctx, bson.M{"_id": bson.M{"$in": sectionIDs}, "unavailable": bson.M{"$ne": true}})
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+	if err = cursor.All(ctx, &evaluations); err != nil {
+		log.WritePanic(err)
+		panic(err)
+	}
+	evaluationsBySection := make(map[primitive.ObjectID]schema.Evaluation)
+	for _, evaluation := range evaluations {
+		evaluationsBySection[evaluation.Id] = evaluation
+	}
+
+	professors := make([]schema.CourseProfessor, len(results))
+	for i, result := range results {
+		professors[i] = result.CourseProfessor
+		professors[i].Grades = combineGradeDistributions(result.Grade_distributions)
+		schema.SortTermNames(professors[i].Terms)
+
+		var professorEvaluations []schema.Evaluation
+		for _, sectionID := range result.Sections {
+			if evaluation, ok := evaluationsBySection[sectionID]; ok {
+				professorEvaluations = append(professorEvaluations, evaluation)
+			}
+		}
+		professors[i].Evaluation = schema.NewEvaluationOverview(professorEvaluations)
+	}
+
+	sort.SliceStable(professors, func(i, j int) bool {
+		var less bool
+		if sortBy == "name" {
+			nameI := strings.ToLower(professors[i].Last_name + " " + professors[i].First_name)
+			nameJ := strings.ToLower(professors[j].Last_name + " " + professors[j].First_name)
+			less = nameI < nameJ
+			if descending {
+				less = nameI > nameJ
+			}
+		} else {
+			metricI, _ := professors[i].Grades.Stats.Metric(sortBy)
+			metricJ, _ := professors[j].Grades.Stats.Metric(sortBy)
+			less = metricI < metricJ
+			if descending {
+				less = metricI > metricJ
+			}
+		}
+		return less
+	})
+
+	// Return result
+	c.JSON(http.StatusOK, responses.CourseProfessorsResponse{Status: http.StatusOK, Message: "success", Data: professors})
+}
@@ -0,0 +1,187 @@
+// Package controllers handles the business logic of the API, including the core curriculum browser, which lists the courses and sections that
+// fulfill each component area of the core curriculum.
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/responses"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CoreAll returns every core curriculum area along with how many courses and sections fulfill it.
+//
+// @Id coreAll
+// @Router /core [get]
+// @Description "Returns all core curriculum areas with the number of courses and sections fulfilling each"
+// @Produce json
+// @Param term query string false "Only count sections from this academic session, e.g. 23F"
+// @Success 200 {array} schema.CoreAreaSummary "A list of core areas"
+func CoreAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var counts []struct {
+		Flag          string `bson:"_id"`
+		Course_count  int    `bson:"course_count"`
+		Section_count int    `bson:"section_count"`
+	}
+
+	sectionMatch := bson.M{"core_flags": bson.M{"$exists": true, "$ne": bson.A{}}}
+	if term := c.Query("term"); term != "" {
+		sectionMatch["academic_session.name"] = term
+	}
+
+	// Count the sections and distinct courses under each core flag
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: sectionMatch}},
+		bson.D{{Key: "$unwind", Value: "$core_flags"}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$core_flags"},
+			{Key: "section_count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "courses", Value: bson.D{{Key: "$addToSet", Value: "$course_reference"}}},
+		}}},
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "section_count", Value: 1},
+			{Key: "course_count", Value: bson.D{{Key: "$size", Value: "$courses"}}},
+		}}},
+	}
+
+	cursor, err := sectionCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// Retrieve and parse all valid documents
+	if err = cursor.All(ctx, &counts); err != nil {
+		log.WritePanic(err)
+		panic(err)
+	}
+
+	// Attach the counts to the registry so that areas without any sections are still listed
+	areas := make([]schema.CoreAreaSummary, len(schema.CoreAreas))
+	for i, area := range schema.CoreAreas {
+		areas[i].CoreArea = area
+		for _, count := range counts {
+			if count.Flag == area.Flag {
+				areas[i].Course_count = count.Course_count
+				areas[i].Section_count = count.Section_count
+			}
+		}
+	}
+
+	// Return result
+	c.JSON(http.StatusOK, responses.CoreAreasResponse{Status: http.StatusOK, Message: "success", Data: areas})
+}
+
+// CoreCourses returns the courses with sections that fulfill the given core area, optionally limited to one academic session, a page at a time.
+//
+// @Id coreCourses
+// @Router /core/{flag}/courses [get]
+// @Description "Returns all courses with sections fulfilling the given core area"
+// @Produce json
+// @Param flag path string true "The core area's code, e.g. 020"
+// @Param term query string false "Only include sections from this academic session, e.g. 23F"
+// @Param grades query boolean false "Include the combined grade distribution and statistics of each course's sections"
+// @Param offset query integer false "The starting position of the current page of courses (e.g. For starting at the 17th course, offset=16)."
+// @Success 200 {array} schema.CoreCourse "A list of courses"
+func CoreCourses(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	flag := c.Param("flag")
+	includeGrades := c.Query("grades") == "true"
+
+	var results []struct {
+		schema.CoreCourse   `bson:",inline"`
+		Grade_distributions [][]int `bson:"grade_distributions"`
+	}
+
+	if _, ok := schema.LookupCoreArea(flag); !ok {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: "Unknown core flag."})
+		return
+	}
+
+	offset, ok := offsetQuery(c)
+	if !ok {
+		return
+	}
+
+	sectionMatch := bson.M{"core_flags": flag}
+	if term := c.Query("term"); term != "" {
+		sectionMatch["academic_session.name"] = term
+	}
+
+	// Group the matching sections by course, then join the course details
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: sectionMatch}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$course_reference"},
+			{Key: "sections", Value: bson.D{{Key: "$push", Value: bson.D{
+				{Key: "_id", Value: "$_id"},
+				{Key: "section_number", Value: "$section_number"},
+				{Key: "academic_session", Value: "$academic_session.name"},
+			}}}},
+			{Key: "grade_distributions", Value: bson.D{{Key: "$push", Value: "$grade_distribution"}}},
+		}}},
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "courses"},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "course"},
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$course"}}}},
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "subject_prefix", Value: "$course.subject_prefix"},
+			{Key: "course_number", Value: "$course.course_number"},
+			{Key: "title", Value: "$course.title"},
+			{Key: "section_count", Value: bson.D{{Key: "$size", Value: "$sections"}}},
+			{Key: "sections", Value: 1},
+			{Key: "grade_distributions", Value: 1},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "subject_prefix", Value: 1},
+			{Key: "course_number", Value: 1},
+			{Key: "_id", Value: 1},
+		}}},
+		bson.D{{Key: "$skip", Value: offset}},
+		bson.D{{Key: "$limit", Value: configs.GetEnvLimit()}},
+	}
+
+	cursor, err := sectionCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// Retrieve and parse all valid documents
+	if err = cursor.All(ctx, &results); err != nil {
+		log.WritePanic(err)
+		panic(err)
+	}
+
+	courses := make([]schema.CoreCourse, len(results))
+	for i, result := range results {
+		courses[i] = result.CoreCourse
+		if includeGrades {
+			// Combine the grade distributions of the course's sections
+			summary := combineGradeDistributions(result.Grade_distributions)
+			courses[i].Grades = &summary
+		}
+	}
+
+	// Return result
+	c.JSON(http.StatusOK, responses.CoreCoursesResponse{Status: http.StatusOK, Message: "success", Data: courses})
+}
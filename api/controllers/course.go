@@ -16,6 +16,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // The courseCollection variable represents the MongoDB collection for courses.
@@ -142,3 +143,25 @@ func CourseAll(c *gin.Context) {
 	// Return result
 	c.JSON(http.StatusOK, responses.MultiCourseResponse{Status: http.StatusOK, Message: "success", Data: courses})
 }
+
+// catalogCourseIDs returns the ids of every catalog year's document for the same course, identified by its subject prefix and course number.
+func catalogCourseIDs(ctx context.Context, course schema.Course) ([]primitive.ObjectID, error) {
+	var courses []schema.Course
+
+	cursor, err := courseCollection.Find(ctx,
+		bson.M{"subject_prefix": course.Subject_prefix, "course_number": course.Course_number},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(ctx, &courses); err != nil {
+		return nil, err
+	}
+
+	courseIDs := make([]primitive.ObjectID, len(courses))
+	for i, doc := range courses {
+		courseIDs[i] = doc.Id
+	}
+	return courseIDs, nil
+}
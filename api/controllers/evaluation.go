@@ -3,50 +3,51 @@
 package controllers
 
 import (
-	"bytes"
 	"context"
-	"errors"
-	"fmt"
 	"net/http"
-	"os"
-	"strings"
-	"sync"
 	"time"
 
+	"github.com/UTDNebula/nebula-api/api/common/evaluations"
 	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/common/ues"
 	"github.com/UTDNebula/nebula-api/api/configs"
 	"github.com/UTDNebula/nebula-api/api/responses"
 	"github.com/UTDNebula/nebula-api/api/schema"
 
 	"github.com/gin-gonic/gin"
 
-	"github.com/chromedp/cdproto/network"
-	"github.com/chromedp/chromedp"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var evaluationCollection *mongo.Collection = configs.GetCollection("evaluations")
 
 // EvalBySectionID handles a GET request to retrieve evaluation data for a specific course section. The function checks if an evaluation for
-// the given section is already stored in MongoDB. If its not stored the program scrapes the evaluation data from UTD's coursebook website on-demand.
+// the given section is already stored in MongoDB. If its not stored, the program queues a job to scrape it from UTD's coursebook website and
+// responds with a link to the job. An evaluation scraped longer ago than the configured maximum age is still served while a job refreshes it.
+// Sections without a report are remembered as such for the configured retry interval, so coursebook isn't asked for them on every request.
+// Requests carrying an API key with the admin scope may pass refresh=true to queue a scrape regardless.
 //
 // Parameters:
 // - c: The Gin context that contains the request and response for the HTTP call.
 //
 // Possible response status codes:
 // - 200: Success with the evaluation data.
+// - 202: A scrape job was queued, with the job in the response and its link in the Location header.
 // - 400: Invalid section ID.
-// - 500: Internal server error during database retrieval or scraping process.
+// - 401: refresh=true was passed without a valid API key.
+// - 403: refresh=true was passed with an API key without the admin scope.
+// - 404: The section doesn't exist or has no evaluation report.
+// - 500: Internal server error during database retrieval.
 func EvalBySectionID(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 
 	sectionId := c.Param("id")
+	refresh := c.Query("refresh") == "true"
 
 	var eval schema.Evaluation
-	var section schema.Section
-	var course schema.Course
 
 	// Ensure the context is canceled once the function returns.
 	defer cancel()
@@ -59,200 +60,109 @@ func EvalBySectionID(c *gin.Context) {
 		return
 	}
 
+	// Only administrators may force a scrape
+	if refresh && !authorizeScope(c, schema.API_KEY_SCOPE_ADMIN) {
+		return
+	}
+
 	// First, check if evaluation already exists for this section in the database
 	err = evaluationCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&eval)
+	cached := err == nil
 
-	// If not, perform on-demand scraping
-	if err != nil {
-		// If err is anything other than the document not existing, it's likely a database issue; notify the user
-		if err != mongo.ErrNoDocuments {
-			log.WriteError(err)
-			c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
-			return
-		}
-
-		// Find and parse matching section using the section ID
-		err = sectionCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&section)
-		if err != nil {
-			log.WriteError(err)
-			c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
-			return
-		}
-
-		// Find the course associated with the section.
-		objId = section.Course_reference
-
-		// Find section and course for scraping
-		err = courseCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&course)
-		if err != nil {
-			log.WriteError(err)
-			c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
-			return
-		}
-
-		// Scrape the evaluation data
-		evalResult, err := ScrapeEval(course, section)
-		if err != nil {
-			log.WriteError(err)
-			c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
-			return
-		}
-		eval = *evalResult
+	// If err is anything other than the document not existing, it's likely a database issue; notify the user
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
 	}
 
-	// Return the evaluation result as a JSON response
-	c.JSON(http.StatusOK, responses.EvaluationResponse{Status: http.StatusOK, Message: "success", Data: eval})
-}
-
-// Performs on-demand scraping for the eval of a given section
-func ScrapeEval(course schema.Course, section schema.Section) (*schema.Evaluation, error) {
-
-	// Make sure chromedp is initialized
-	chromedpCtx, cancel := initChromeDp()
-	defer cancel()
-
-	// Get auth headers
-	headers := refreshToken(chromedpCtx)
-
-	// Build the section ID string based on course and section details.
-	sectionID := course.Subject_prefix + course.Course_number + "." + section.Section_number + "." + section.Academic_session.Name
-
-	log.WriteDebug(fmt.Sprintf("Finding eval for %s", sectionID))
-
-	// Get eval info
-	evalURL := fmt.Sprintf("https://coursebook.utdallas.edu/ues-report/%s", sectionID)
-
-	// Navigate to eval URL and pull all HTML
-	bodyReader := strings.NewReader("")
-	req, err := http.NewRequest("GET", evalURL, bodyReader)
-	if err != nil {
-		panic(err)
+	// Serve the stored evaluation while it's fresh
+	if cached && !refresh && !evaluationStale(eval) {
+		respondWithEvaluation(c, eval)
+		return
 	}
 
-	req.Header = headers
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		panic(err)
+	// Make sure the section exists before queueing a scrape of it
+	err = sectionCollection.FindOne(ctx, bson.M{"_id": objId}, options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, responses.ErrorResponse{Status: http.StatusNotFound, Message: "error", Data: err.Error()})
+		return
 	}
-	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("section find failed! Status was: %s\nIf the status is 404, you've likely been IP ratelimited", res.Status)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
 	}
-	// Read the response body into a buffer
-	buf := bytes.Buffer{}
-	buf.ReadFrom(res.Body)
 
-	// Write the HTML content to a file for testing purposes
-	file, err := os.Create("./HTML_TEST.html")
+	job, err := evaluations.Enqueue(ctx, objId)
 	if err != nil {
-		return nil, err
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
 	}
-	defer file.Close()
-
-	file.Write(buf.Bytes())
-
-	// TODO: Perform HTML parsing and build eval
 
-	// Return an empty evaluation for now
-	return &schema.Evaluation{}, nil
-}
-
-// The 2 functions below are copied from API-Tools to support on-demand scraping
-var chromeDpMutex sync.Mutex
+	// Serve a stale evaluation while it's refreshed in the background
+	if cached && !refresh && !eval.Unavailable {
+		respondWithEvaluation(c, eval)
+		return
+	}
 
-// Initializes chromedp using the default executable allocator, this locks the mutex to ensure the initialization is thread-safe.
-func initChromeDp() (chromedpCtx context.Context, cancelFnc context.CancelFunc) {
-	chromeDpMutex.Lock()
-	log.WriteDebug("Initializing chromedp...")
-	allocCtx, cancelFnc := chromedp.NewExecAllocator(context.Background())
-	chromedpCtx, _ = chromedp.NewContext(allocCtx)
-	log.WriteDebug("Initialized chromedp!")
-	chromeDpMutex.Unlock()
-	return
+	c.Header("Location", job.Job_uri)
+	c.JSON(http.StatusAccepted, responses.ScrapeJobResponse{Status: http.StatusAccepted, Message: "success", Data: job})
 }
 
-const tokenRateLimit time.Duration = time.Second * 10
-
-var lastTokenTime time.Time = time.Date(2003, time.March, 21, 7, 47, 0, 0, time.Now().Location())
-var cachedCookie map[string][]string
-
-// refreshToken refreshes the authentication token used for scraping the UTD coursebook and generates a fresh auth token
-// It uses a mutex lock to avoid overlapping token refreshes when multiple requests occur simultaneously.
-//
-// Parameters:
-// - chromedpCtx: The ChromeDP context used for web scraping.
+// EvaluationJobById returns the status of an evaluation scrape job, along with a link to the evaluation once it has succeeded.
 //
-// Returns:
-// - A map of headers, including the updated cookies.
-func refreshToken(chromedpCtx context.Context) map[string][]string {
-
-	// Due to how Gin works, multiple request goroutines may try to refresh their token simultaneously; wrap this area in a mutex lock so as to avoid overlapping refreshes
-	chromeDpMutex.Lock()
-
-	// Just return the last cached cookie if we're exceeding the ratelimit
-	if time.Since(lastTokenTime) < tokenRateLimit {
-		return cachedCookie
-	}
-
-	// Retrieve the NetID and password from the environment variables.
-	netID, password := configs.GetEnvLogin()
+// @Id evaluationJobById
+// @Router /evaluation/jobs/{id} [get]
+// @Description "Returns the status of the evaluation scrape job with the given ID"
+// @Produce json
+// @Param id path string true "ID of the scrape job to get"
+// @Success 200 {object} schema.ScrapeJob "A scrape job"
+func EvaluationJobById(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	log.WriteDebug("Getting new token...")
-	// Clear browser cookies, navigate to the login page, and authenticate using chromedp.
-	_, err := chromedp.RunResponse(chromedpCtx,
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			err := network.ClearBrowserCookies().Do(ctx)
-			return err
-		}),
-		chromedp.Navigate(`https://wat.utdallas.edu/login`),
-		chromedp.WaitVisible(`form#login-form`),
-		chromedp.SendKeys(`input#netid`, netID),
-		chromedp.SendKeys(`input#password`, password),
-		chromedp.Click(`input#login-button`),
-		chromedp.WaitVisible(`body`),
-	)
+	// Parse object id from id parameter
+	objId, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		panic(err)
+		log.WriteError(err)
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
 	}
 
-	// Retrieve cookies from the browser session after login.
-	var cookieStrs []string
-	_, err = chromedp.RunResponse(chromedpCtx,
-		chromedp.Navigate(`https://coursebook.utdallas.edu/`),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			cookies, err := network.GetCookies().Do(ctx)
-			cookieStrs = make([]string, len(cookies))
-			gotToken := false
-			for i, cookie := range cookies {
-				cookieStrs[i] = fmt.Sprintf("%s=%s", cookie.Name, cookie.Value)
-				if cookie.Name == "PTGSESSID" {
-					log.WriteDebug(fmt.Sprintf("Got new token: PTGSESSID = %s", cookie.Value))
-					gotToken = true
-				}
-			}
-			if !gotToken {
-				return errors.New("failed to get a new token")
-			}
-			return err
-		}),
-	)
+	// Find and parse matching job
+	job, err := evaluations.FindJob(ctx, objId)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, responses.ErrorResponse{Status: http.StatusNotFound, Message: "error", Data: err.Error()})
+		return
+	}
 	if err != nil {
-		panic(err)
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
 	}
 
-	// Cache the retrieved cookies for future requests.
-	cachedCookie = map[string][]string{
-		"Host":            {"coursebook.utdallas.edu"},
-		"User-Agent":      {"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:109.0) Gecko/20100101 Firefox/110.0"},
-		"Accept":          {"text/html"},
-		"Accept-Language": {"en-US"},
-		"Content-Type":    {"application/x-www-form-urlencoded"},
-		"Cookie":          cookieStrs,
-		"Connection":      {"keep-alive"},
-	}
+	c.JSON(http.StatusOK, responses.ScrapeJobResponse{Status: http.StatusOK, Message: "success", Data: job})
+}
 
-	// Unlock the mutex now that we've cached a cookie
-	chromeDpMutex.Unlock()
+// evaluationStale reports whether a stored evaluation should be scraped again. Evaluations imported in bulk have no fetch time and never go stale.
+func evaluationStale(eval schema.Evaluation) bool {
+	if eval.Fetched_at == nil {
+		return false
+	}
+	maxAge := configs.GetEnvEvaluationMaxAge()
+	if eval.Unavailable {
+		maxAge = configs.GetEnvEvaluationRetryInterval()
+	}
+	return time.Since(*eval.Fetched_at) > maxAge
+}
 
-	// Return the updated cookie headers
-	return cachedCookie
+// respondWithEvaluation writes an evaluation as the response, or a 404 if the section is known to have no report.
+func respondWithEvaluation(c *gin.Context, eval schema.Evaluation) {
+	if eval.Unavailable {
+		c.JSON(http.StatusNotFound, responses.ErrorResponse{Status: http.StatusNotFound, Message: "error", Data: ues.ErrNoQuestions.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, responses.EvaluationResponse{Status: http.StatusOK, Message: "success", Data: eval})
 }
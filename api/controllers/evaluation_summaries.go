@@ -0,0 +1,414 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/responses"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// How long the mean scores of a department's courses or professors are reused before being aggregated again.
+const departmentEvaluationCacheTTL = time.Hour
+
+// The most sets of department mean scores kept in the cache at once. When it's full, the set closest to expiring makes room for the new one.
+const departmentEvaluationCacheMaxEntries = 256
+
+type departmentEvaluationCacheEntry struct {
+	means   map[string]float64
+	expires time.Time
+}
+
+// Department mean scores are cached by department, kind and session filter, since every course or professor of a department ranks against
+// the same ones.
+var departmentEvaluationCache = make(map[string]departmentEvaluationCacheEntry)
+var departmentEvaluationCacheMutex sync.Mutex
+
+// ProfessorEvaluations combines the evaluations of every section a professor has taught, and ranks the professor's mean score among the other
+// professors of their department.
+//
+// @Id professorEvaluations
+// @Router /professor/{id}/evaluations [get]
+// @Description "Returns the combined evaluations of the professor with given ID"
+// @Produce json
+// @Param id path string true "ID of the professor to get evaluations for"
+// @Param term query string false "Only include this academic session, e.g. 23F"
+// @Param from query string false "Only include academic sessions from this one onwards, e.g. 21F"
+// @Param to query string false "Only include academic sessions up to and including this one, e.g. 23S"
+// @Param exclude_summer query boolean false "Leave out summer academic sessions"
+// @Success 200 {object} schema.EvaluationAggregate "The professor's combined evaluations"
+func ProfessorEvaluations(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var professor schema.Professor
+
+	// Parse object id from id parameter
+	objId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+
+	sessionFilter, ok := evaluationSessionFilter(c)
+	if !ok {
+		return
+	}
+
+	// Find and parse matching professor
+	err = professorCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&professor)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	sectionMatch := bson.D{{Key: "professors", Value: objId}}
+	if sessionFilter != nil {
+		sectionMatch = append(sectionMatch, bson.E{Key: "academic_session.name", Value: sessionFilter})
+	}
+
+	aggregate, sections, err := aggregateSectionEvaluations(ctx, sectionMatch)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// The professor's department is the subject prefix of most of their evaluated sections
+	if len(sections) > 0 {
+		prefix, err := mostCommonPrefix(ctx, sections)
+		if err != nil {
+			log.WriteError(err)
+			c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+			return
+		}
+		means, err := departmentEvaluationMeans(ctx, prefix, sessionFilter, true)
+		if err != nil {
+			log.WriteError(err)
+			c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+			return
+		}
+		aggregate.Department = departmentRank(prefix, means, objId.Hex())
+	}
+
+	c.JSON(http.StatusOK, responses.EvaluationAggregateResponse{Status: http.StatusOK, Message: "success", Data: aggregate})
+}
+
+// CourseEvaluations combines the evaluations of every section of a course, across every catalog year, and ranks the course's mean score among
+// the other courses of its subject prefix.
+//
+// @Id courseEvaluations
+// @Router /course/{id}/evaluations [get]
+// @Description "Returns the combined evaluations of the course with given ID"
+// @Produce json
+// @Param id path string true "ID of the course to get evaluations for"
+// @Param term query string false "Only include this academic session, e.g. 23F"
+// @Param from query string false "Only include academic sessions from this one onwards, e.g. 21F"
+// @Param to query string false "Only include academic sessions up to and including this one, e.g. 23S"
+// @Param exclude_summer query boolean false "Leave out summer academic sessions"
+// @Success 200 {object} schema.EvaluationAggregate "The course's combined evaluations"
+func CourseEvaluations(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var course schema.Course
+
+	// Parse object id from id parameter
+	objId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+
+	sessionFilter, ok := evaluationSessionFilter(c)
+	if !ok {
+		return
+	}
+
+	// Find and parse matching course
+	err = courseCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&course)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	courseIDs, err := catalogCourseIDs(ctx, course)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	sectionMatch := bson.D{{Key: "course_reference", Value: bson.D{{Key: "$in", Value: courseIDs}}}}
+	if sessionFilter != nil {
+		sectionMatch = append(sectionMatch, bson.E{Key: "academic_session.name", Value: sessionFilter})
+	}
+
+	aggregate, sections, err := aggregateSectionEvaluations(ctx, sectionMatch)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	if len(sections) > 0 {
+		means, err := departmentEvaluationMeans(ctx, course.Subject_prefix, sessionFilter, false)
+		if err != nil {
+			log.WriteError(err)
+			c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+			return
+		}
+		aggregate.Department = departmentRank(course.Subject_prefix, means, course.Course_number)
+	}
+
+	c.JSON(http.StatusOK, responses.EvaluationAggregateResponse{Status: http.StatusOK, Message: "success", Data: aggregate})
+}
+
+// evaluationSessionFilter parses the term, from, to and exclude_summer parameters into a filter on academic session names, responding with a
+// 400 and returning false if they're invalid.
+func evaluationSessionFilter(c *gin.Context) (interface{}, bool) {
+	var filters schema.GradeFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return nil, false
+	}
+	sessionFilter, err := gradeSessionFilter(filters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return nil, false
+	}
+	return sessionFilter, true
+}
+
+// aggregateSectionEvaluations combines the stored evaluations of the sections matching sectionMatch, returning the sections that had one.
+func aggregateSectionEvaluations(ctx context.Context, sectionMatch bson.D) (schema.EvaluationAggregate, []schema.Section, error) {
+	var sections []schema.Section
+	var evaluations []schema.Evaluation
+
+	cursor, err := sectionCollection.Find(ctx, sectionMatch, options.Find().
+		SetProjection(bson.M{"course_reference": 1, "academic_session.name": 1}))
+	if err != nil {
+		return schema.EvaluationAggregate{}, nil, err
+	}
+	if err = cursor.All(ctx, &sections); err != nil {
+		return schema.EvaluationAggregate{}, nil, err
+	}
+
+	sessions := make(map[primitive.ObjectID]string)
+	sectionIDs := []primitive.ObjectID{}
+	for _, section := range sections {
+		sessions[section.Id] = section.Academic_session.Name
+		sectionIDs = append(sectionIDs, section.Id)
+	}
+
+	cursor, err = evaluationCollection.Find(ctx, bson.M{"_id": bson.M{"$in": sectionIDs}, "unavailable": bson.M{"$ne": true}})
+	if err != nil {
+		return schema.EvaluationAggregate{}, nil, err
+	}
+	if err = cursor.All(ctx, &evaluations); err != nil {
+		return schema.EvaluationAggregate{}, nil, err
+	}
+
+	evaluated := make(map[primitive.ObjectID]bool)
+	for _, evaluation := range evaluations {
+		evaluated[evaluation.Id] = true
+	}
+	var evaluatedSections []schema.Section
+	for _, section := range sections {
+		if evaluated[section.Id] {
+			evaluatedSections = append(evaluatedSections, section)
+		}
+	}
+
+	return schema.NewEvaluationAggregate(evaluations, sessions), evaluatedSections, nil
+}
+
+// mostCommonPrefix returns the subject prefix shared by the most of the given sections, breaking ties alphabetically.
+func mostCommonPrefix(ctx context.Context, sections []schema.Section) (string, error) {
+	var courses []schema.Course
+
+	courseIDs := []primitive.ObjectID{}
+	for _, section := range sections {
+		courseIDs = append(courseIDs, section.Course_reference)
+	}
+	cursor, err := courseCollection.Find(ctx, bson.M{"_id": bson.M{"$in": courseIDs}}, options.Find().SetProjection(bson.M{"subject_prefix": 1}))
+	if err != nil {
+		return "", err
+	}
+	if err = cursor.All(ctx, &courses); err != nil {
+		return "", err
+	}
+
+	prefixes := make(map[primitive.ObjectID]string)
+	for _, course := range courses {
+		prefixes[course.Id] = course.Subject_prefix
+	}
+	counts := make(map[string]int)
+	best := ""
+	for _, section := range sections {
+		prefix := prefixes[section.Course_reference]
+		counts[prefix]++
+		if counts[prefix] > counts[best] || (counts[prefix] == counts[best] && prefix < best) {
+			best = prefix
+		}
+	}
+	return best, nil
+}
+
+// departmentEvaluationMeans returns the mean score of every course of a department, keyed by course number, or of every professor who taught
+// in it, keyed by professor id. Each mean is the mean of every question's mean, weighted by the question's responses.
+func departmentEvaluationMeans(ctx context.Context, prefix string, sessionFilter interface{}, byProfessor bool) (map[string]float64, error) {
+	cacheKey := fmt.Sprintf("%s|%t|%v", prefix, byProfessor, sessionFilter)
+
+	departmentEvaluationCacheMutex.Lock()
+	entry, cached := departmentEvaluationCache[cacheKey]
+	departmentEvaluationCacheMutex.Unlock()
+	if cached && time.Now().Before(entry.expires) {
+		return entry.means, nil
+	}
+
+	var courses []schema.Course
+	var results []struct {
+		Id        primitive.ObjectID `bson:"_id"`
+		Total     float64            `bson:"total"`
+		Responses int                `bson:"responses"`
+	}
+
+	cursor, err := courseCollection.Find(ctx, bson.M{"subject_prefix": prefix}, options.Find().SetProjection(bson.M{"course_number": 1}))
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(ctx, &courses); err != nil {
+		return nil, err
+	}
+	numbers := make(map[primitive.ObjectID]string)
+	courseIDs := []primitive.ObjectID{}
+	for _, course := range courses {
+		numbers[course.Id] = course.Course_number
+		courseIDs = append(courseIDs, course.Id)
+	}
+
+	sectionMatch := bson.D{{Key: "course_reference", Value: bson.D{{Key: "$in", Value: courseIDs}}}}
+	if sessionFilter != nil {
+		sectionMatch = append(sectionMatch, bson.E{Key: "academic_session.name", Value: sessionFilter})
+	}
+	groupKey := "$course_reference"
+
+	// Join each section's evaluation, then total every question's mean weighted by its responses
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: sectionMatch}},
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "evaluations"},
+			{Key: "localField", Value: "_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "evaluation"},
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$evaluation"}}}},
+		bson.D{{Key: "$match", Value: bson.D{{Key: "evaluation.unavailable", Value: bson.D{{Key: "$ne", Value: true}}}}}},
+	}
+	if byProfessor {
+		pipeline = append(pipeline, bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$professors"}}}})
+		groupKey = "$professors"
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "key", Value: groupKey},
+			{Key: "fields", Value: bson.D{{Key: "$concatArrays", Value: bson.A{
+				bson.D{{Key: "$ifNull", Value: bson.A{"$evaluation.course_experience", bson.A{}}}},
+				bson.D{{Key: "$ifNull", Value: bson.A{"$evaluation.instructor_experience", bson.A{}}}},
+				bson.D{{Key: "$ifNull", Value: bson.A{"$evaluation.student_experience", bson.A{}}}},
+			}}}},
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$fields"}}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$key"},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$multiply", Value: bson.A{"$fields.summary.mean", "$fields.summary.responses"}}}}}},
+			{Key: "responses", Value: bson.D{{Key: "$sum", Value: "$fields.summary.responses"}}},
+		}}},
+	)
+
+	cursor, err = sectionCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	// Courses are listed once per catalog year, so their totals are combined by course number
+	totals := make(map[string]float64)
+	responseCounts := make(map[string]int)
+	for _, result := range results {
+		key := result.Id.Hex()
+		if !byProfessor {
+			key = numbers[result.Id]
+		}
+		totals[key] += result.Total
+		responseCounts[key] += result.Responses
+	}
+	means := make(map[string]float64)
+	for key, responses := range responseCounts {
+		if responses > 0 {
+			means[key] = totals[key] / float64(responses)
+		}
+	}
+
+	storeDepartmentEvaluationMeans(cacheKey, departmentEvaluationCacheEntry{means: means, expires: time.Now().Add(departmentEvaluationCacheTTL)})
+	return means, nil
+}
+
+// storeDepartmentEvaluationMeans caches a department's mean scores, first dropping the expired ones and, if the cache is still full, the set
+// closest to expiring.
+func storeDepartmentEvaluationMeans(key string, entry departmentEvaluationCacheEntry) {
+	departmentEvaluationCacheMutex.Lock()
+	defer departmentEvaluationCacheMutex.Unlock()
+
+	now := time.Now()
+	for cachedKey, old := range departmentEvaluationCache {
+		if now.After(old.expires) {
+			delete(departmentEvaluationCache, cachedKey)
+		}
+	}
+	if _, replacing := departmentEvaluationCache[key]; !replacing && len(departmentEvaluationCache) >= departmentEvaluationCacheMaxEntries {
+		oldestKey, oldest := "", time.Time{}
+		for cachedKey, old := range departmentEvaluationCache {
+			if oldestKey == "" || old.expires.Before(oldest) {
+				oldestKey, oldest = cachedKey, old.expires
+			}
+		}
+		delete(departmentEvaluationCache, oldestKey)
+	}
+	departmentEvaluationCache[key] = entry
+}
+
+// departmentRank ranks the mean of key among the means of its department, returning nil if key has no mean.
+func departmentRank(prefix string, means map[string]float64, key string) *schema.DepartmentRank {
+	mean, ok := means[key]
+	if !ok {
+		return nil
+	}
+	values := make([]float64, 0, len(means))
+	for _, other := range means {
+		values = append(values, other)
+	}
+	return &schema.DepartmentRank{
+		Subject_prefix: prefix,
+		Compared:       len(values),
+		Percentile:     schema.PercentileRank(values, mean),
+	}
+}
@@ -0,0 +1,352 @@
+// Package controllers handles the business logic of the API, including the campus events calendar and its iCalendar feeds.
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	_ "time/tzdata" // the campus time zone must load even where the system has no zoneinfo
+
+	"github.com/UTDNebula/nebula-api/api/common/ical"
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/responses"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var eventCollection *mongo.Collection = configs.GetCollection("events")
+
+// Without a time window, feeds include the events of this long ago onwards, so subscribers still see recent events.
+const eventFeedHistory = 30 * 24 * time.Hour
+
+// The most events a feed includes, since feeds aren't paginated.
+const eventFeedLimit = 1000
+
+// How often subscribers are asked to fetch a feed again.
+const eventFeedRefresh = time.Hour
+
+// Dates without a time are days on campus.
+var campusLocation = mustLoadLocation("America/Chicago")
+
+// eventFacets maps each facet query parameter to the event field it filters on.
+var eventFacets = []struct {
+	param string
+	field string
+}{
+	{"type", "event_type"},
+	{"audience", "target_audience"},
+	{"topic", "topic"},
+	{"department", "department"},
+	{"tag", "event_tags"},
+}
+
+// @Id eventSearch
+// @Router /event [get]
+// @Description "Returns all events in the time window matching the facets, ordered by start time"
+// @Produce json
+// @Param from query string false "Only include events ending at or after this time (RFC 3339) or on or after this date (YYYY-MM-DD)"
+// @Param to query string false "Only include events starting before this time (RFC 3339) or on or before this date (YYYY-MM-DD)"
+// @Param upcoming query bool false "Only include events that haven't ended yet"
+// @Param type query []string false "An event type the event must have; repeat to allow several" collectionFormat(multi)
+// @Param audience query []string false "A target audience the event must have; repeat to allow several" collectionFormat(multi)
+// @Param topic query []string false "A topic the event must have; repeat to allow several" collectionFormat(multi)
+// @Param department query []string false "A department the event must be held by; repeat to allow several" collectionFormat(multi)
+// @Param tag query []string false "A tag the event must have; repeat to allow several" collectionFormat(multi)
+// @Param offset query integer false "The starting position of the current page of events (e.g. For starting at the 17th event, offset=16)."
+// @Success 200 {array} schema.Event "A list of events"
+// @Failure 400 {object} responses.ErrorResponse "A time window parameter is invalid"
+func EventSearch(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+	var events []schema.Event
+
+	defer cancel()
+
+	query, err := eventQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+
+	optionLimit, err := configs.GetOptionLimit(&query, c)
+	if err != nil {
+		log.WriteErrorWithMsg(err, log.OffsetNotTypeInteger)
+		c.JSON(http.StatusConflict, responses.ErrorResponse{Status: http.StatusConflict, Message: "Error offset is not type integer", Data: err.Error()})
+		return
+	}
+
+	// get cursor for query results
+	cursor, err := eventCollection.Find(ctx, query, optionLimit.SetSort(bson.D{{Key: "start_time", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// retrieve and parse all valid documents
+	if err = cursor.All(ctx, &events); err != nil {
+		log.WritePanic(err)
+		panic(err)
+	}
+
+	// return result
+	c.JSON(http.StatusOK, responses.MultiEventResponse{Status: http.StatusOK, Message: "success", Data: events})
+}
+
+// @Id eventById
+// @Router /event/{id} [get]
+// @Description "Returns the event with given ID"
+// @Produce json
+// @Param id path string true "ID of the event to get"
+// @Success 200 {object} schema.Event "An event"
+func EventById(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+	eventId := c.Param("id")
+
+	var event schema.Event
+
+	defer cancel()
+
+	// parse object id from id parameter
+	objId, err := primitive.ObjectIDFromHex(eventId)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// find and parse matching event
+	err = eventCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&event)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// return result
+	c.JSON(http.StatusOK, responses.SingleEventResponse{Status: http.StatusOK, Message: "success", Data: event})
+}
+
+// EventFeed returns the events matching the same filters as EventSearch as an iCalendar feed that calendar apps can subscribe to, e.g.
+// /event/feed.ics?department=Computer%20Science for every event of the CS department. Feeds aren't paginated; without a time window they hold
+// the events of the last 30 days onwards.
+//
+// @Id eventFeed
+// @Router /event/feed.ics [get]
+// @Description "Returns the events in the time window matching the facets as an iCalendar feed"
+// @Produce text/calendar
+// @Param from query string false "Only include events ending at or after this time (RFC 3339) or on or after this date (YYYY-MM-DD)"
+// @Param to query string false "Only include events starting before this time (RFC 3339) or on or before this date (YYYY-MM-DD)"
+// @Param upcoming query bool false "Only include events that haven't ended yet"
+// @Param type query []string false "An event type the event must have; repeat to allow several" collectionFormat(multi)
+// @Param audience query []string false "A target audience the event must have; repeat to allow several" collectionFormat(multi)
+// @Param topic query []string false "A topic the event must have; repeat to allow several" collectionFormat(multi)
+// @Param department query []string false "A department the event must be held by; repeat to allow several" collectionFormat(multi)
+// @Param tag query []string false "A tag the event must have; repeat to allow several" collectionFormat(multi)
+// @Success 200 {file} file "An iCalendar feed of events"
+// @Success 304 "The feed hasn't changed since the ETag in If-None-Match"
+// @Failure 400 {object} responses.ErrorResponse "A time window parameter is invalid"
+func EventFeed(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var events []schema.Event
+
+	query, err := eventQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+	if c.Query("from") == "" && c.Query("to") == "" && c.Query("upcoming") != "true" {
+		query["end_time"] = bson.M{"$gte": time.Now().Add(-eventFeedHistory)}
+	}
+
+	cursor, err := eventCollection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "start_time", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(eventFeedLimit))
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+	if err = cursor.All(ctx, &events); err != nil {
+		log.WritePanic(err)
+		panic(err)
+	}
+
+	calendar := ical.Calendar{
+		Name:            eventFeedName(c),
+		Description:     "UTD campus events from the Nebula API",
+		RefreshInterval: eventFeedRefresh,
+	}
+	var lastChange time.Time
+	for _, event := range events {
+		calendar.Events = append(calendar.Events, icalEvent(event))
+		if changed := eventLastModified(event); changed.After(lastChange) {
+			lastChange = changed
+		}
+	}
+
+	// Stamp the events with when they were last added or changed rather than the time of the request, so the ETag only changes with the events
+	var feed bytes.Buffer
+	if err := ical.Write(&feed, calendar, lastChange); err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	etag := contentETag(feed.Bytes())
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Header("Content-Disposition", `inline; filename="events.ics"`)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", feed.Bytes())
+}
+
+// eventQuery builds the query for the time window and facets of a request. An event is in the window if any of it overlaps the window, so
+// events already underway are included.
+func eventQuery(c *gin.Context) (bson.M, error) {
+	query := bson.M{}
+
+	var from, to time.Time
+	if value := c.Query("from"); value != "" {
+		start, _, err := parseEventTime(value)
+		if err != nil {
+			return nil, errors.New("invalid from parameter, expected an RFC 3339 time or a YYYY-MM-DD date")
+		}
+		from = start
+	}
+	if value := c.Query("to"); value != "" {
+		start, end, err := parseEventTime(value)
+		if err != nil {
+			return nil, errors.New("invalid to parameter, expected an RFC 3339 time or a YYYY-MM-DD date")
+		}
+		// A date includes the whole day
+		to = start
+		if !end.IsZero() {
+			to = end
+		}
+	}
+	switch c.Query("upcoming") {
+	case "", "false":
+	case "true":
+		if now := time.Now(); now.After(from) {
+			from = now
+		}
+	default:
+		return nil, errors.New("invalid upcoming parameter, expected true or false")
+	}
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		return nil, errors.New("the to parameter must be after the from parameter")
+	}
+
+	if !from.IsZero() {
+		query["end_time"] = bson.M{"$gte": from}
+	}
+	if !to.IsZero() {
+		query["start_time"] = bson.M{"$lt": to}
+	}
+
+	// Values of the same facet are alternatives, while different facets must all match
+	for _, facet := range eventFacets {
+		values := c.QueryArray(facet.param)
+		if len(values) == 0 {
+			continue
+		}
+		patterns := bson.A{}
+		for _, value := range values {
+			patterns = append(patterns, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(strings.TrimSpace(value)) + "$", Options: "i"})
+		}
+		query[facet.field] = bson.M{"$in": patterns}
+	}
+
+	return query, nil
+}
+
+// parseEventTime parses an RFC 3339 time, or a date on campus. For a date, it also returns the start of the following day.
+func parseEventTime(value string) (time.Time, time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, time.Time{}, nil
+	}
+	day, err := time.ParseInLocation(time.DateOnly, value, campusLocation)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return day, day.AddDate(0, 0, 1), nil
+}
+
+// eventFeedName names a feed after its facets, e.g. "UTD Events: Computer Science".
+func eventFeedName(c *gin.Context) string {
+	var parts []string
+	for _, facet := range eventFacets {
+		parts = append(parts, c.QueryArray(facet.param)...)
+	}
+	if len(parts) == 0 {
+		return "UTD Events"
+	}
+	return "UTD Events: " + strings.Join(parts, ", ")
+}
+
+// icalEvent converts an event to its iCalendar form. Its types, topics and tags become the categories calendar apps can filter on.
+func icalEvent(event schema.Event) ical.Event {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, category := range append(append(append([]string{}, event.EventType...), event.Topic...), event.EventTags...) {
+		if key := strings.ToLower(category); category != "" && !seen[key] {
+			seen[key] = true
+			categories = append(categories, category)
+		}
+	}
+
+	var contact []string
+	for _, part := range []string{event.ContactName, event.ContactEmail, event.ContactPhoneNumber} {
+		if part = strings.TrimSpace(part); part != "" {
+			contact = append(contact, part)
+		}
+	}
+
+	return ical.Event{
+		UID:          event.Id.Hex() + "@api.utdnebula.com",
+		Summary:      event.Summary,
+		Description:  event.Description,
+		Location:     event.Location,
+		URL:          event.EventWebsite,
+		Start:        event.StartTime,
+		End:          event.EndTime,
+		Categories:   categories,
+		Contact:      strings.Join(contact, ", "),
+		LastModified: eventLastModified(event),
+	}
+}
+
+// eventLastModified is when an event was last changed, or when it was added if its changes aren't recorded.
+func eventLastModified(event schema.Event) time.Time {
+	if event.Updated_at.After(event.Id.Timestamp()) {
+		return event.Updated_at
+	}
+	return event.Id.Timestamp()
+}
+
+// mustLoadLocation loads a time zone, which always succeeds since the time zone database is embedded.
+func mustLoadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return location
+}
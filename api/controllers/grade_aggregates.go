@@ -0,0 +1,407 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The grade_aggregates collection holds the summed grade distribution of every course, professor and course+professor pair in each academic
+// session, so the grades endpoints can answer common queries with a single indexed find instead of the live pipeline.
+//
+// The whole collection is rebuilt on startup (when older than the refresh interval) and on every interval after. Between rebuilds, a change
+// stream on the sections collection recomputes the aggregates of each section that is inserted or updated. Deleted sections, and professors
+// removed from a section, are only caught by the next rebuild since the change stream doesn't say which aggregates they were part of.
+var gradeAggregateCollection *mongo.Collection = configs.GetCollection("grade_aggregates")
+
+// The _id of the document in grade_aggregates recording when the collection was last rebuilt.
+const gradeAggregateRefreshID = "refresh"
+
+// How long a full rebuild of the grade aggregates may run.
+const gradeAggregateRefreshTimeout = 30 * time.Minute
+
+// gradeAggregateKinds are the kinds of grade aggregates, each rebuilt by its own pipeline.
+var gradeAggregateKinds = []string{
+	schema.GRADE_AGGREGATE_COURSE,
+	schema.GRADE_AGGREGATE_PROFESSOR,
+	schema.GRADE_AGGREGATE_COURSE_PROFESSOR,
+}
+
+// gradeAggregatesReady is set once the collection has been rebuilt at least once, after which queries are answered from it.
+var gradeAggregatesReady atomic.Bool
+
+// gradeAggregateRefreshMutex is held while the collection is being rebuilt, so only one rebuild runs at a time.
+var gradeAggregateRefreshMutex sync.Mutex
+
+// StartGradeAggregates keeps the grade_aggregates collection up to date for as long as the server runs.
+func StartGradeAggregates() {
+	ctx := context.Background()
+	interval := configs.GetEnvGradeRefreshInterval()
+
+	_, err := gradeAggregateCollection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{
+		{Key: "kind", Value: 1},
+		{Key: "subject_prefix", Value: 1},
+		{Key: "course_number", Value: 1},
+		{Key: "professor_reference", Value: 1},
+		{Key: "academic_session", Value: 1},
+	}})
+	if err != nil {
+		log.WriteError(err)
+	}
+
+	// Serve from a collection left by a previous run right away, even if it's due to be rebuilt
+	var refresh struct {
+		Refreshed_at time.Time `bson:"refreshed_at"`
+	}
+	err = gradeAggregateCollection.FindOne(ctx, bson.M{"_id": gradeAggregateRefreshID}).Decode(&refresh)
+	if err == nil {
+		gradeAggregatesReady.Store(true)
+	}
+
+	go func() {
+		if err != nil || time.Since(refresh.Refreshed_at) >= interval {
+			startGradeAggregateRefresh()
+		}
+		for range time.Tick(interval) {
+			startGradeAggregateRefresh()
+		}
+	}()
+	go watchSectionChanges(ctx)
+}
+
+// startGradeAggregateRefresh rebuilds the grade aggregates in the background, returning false if a rebuild is already running.
+func startGradeAggregateRefresh() bool {
+	if !gradeAggregateRefreshMutex.TryLock() {
+		return false
+	}
+
+	go func() {
+		defer gradeAggregateRefreshMutex.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), gradeAggregateRefreshTimeout)
+		defer cancel()
+
+		if err := refreshGradeAggregates(ctx); err != nil {
+			log.WriteError(err)
+		}
+	}()
+	return true
+}
+
+// refreshGradeAggregates rebuilds every grade aggregate from the sections collection, then removes the aggregates that no longer have sections.
+func refreshGradeAggregates(ctx context.Context) error {
+	refreshedAt := time.Now()
+
+	for _, kind := range gradeAggregateKinds {
+		if err := mergeGradeAggregates(ctx, kind, bson.D{}, nil, refreshedAt); err != nil {
+			return err
+		}
+	}
+
+	_, err := gradeAggregateCollection.DeleteMany(ctx, bson.D{
+		{Key: "kind", Value: bson.D{{Key: "$in", Value: gradeAggregateKinds}}},
+		{Key: "refreshed_at", Value: bson.D{{Key: "$lt", Value: refreshedAt}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = gradeAggregateCollection.UpdateOne(ctx,
+		bson.M{"_id": gradeAggregateRefreshID},
+		bson.M{"$set": bson.M{"refreshed_at": refreshedAt}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	gradeAggregatesReady.Store(true)
+	log.WriteDebug("Refreshed grade aggregates in " + time.Since(refreshedAt).String())
+	return nil
+}
+
+// watchSectionChanges recomputes the grade aggregates of every section inserted or updated while the server runs. Change streams need a replica
+// set, so on a standalone server the aggregates only change on each rebuild.
+func watchSectionChanges(ctx context.Context) {
+	stream, err := sectionCollection.Watch(ctx, mongo.Pipeline{}, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+	if err != nil {
+		log.WriteErrorWithMsg(err, "Grade aggregates will only be updated on each refresh")
+		return
+	}
+	defer stream.Close(ctx)
+
+	for stream.Next(ctx) {
+		var event struct {
+			FullDocument *schema.Section `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&event); err != nil {
+			log.WriteError(err)
+			continue
+		}
+		// Deletions carry no document to tell which aggregates the section was part of
+		if event.FullDocument == nil {
+			continue
+		}
+		if err := refreshSectionAggregates(ctx, *event.FullDocument); err != nil {
+			log.WriteError(err)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		log.WriteError(err)
+	}
+}
+
+// refreshSectionAggregates recomputes the aggregates a section belongs to: its course's, its professors', and each of its professors' for its
+// course, all in the section's academic session.
+func refreshSectionAggregates(ctx context.Context, section schema.Section) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	var course schema.Course
+	if err := courseCollection.FindOne(ctx, bson.M{"_id": section.Course_reference}).Decode(&course); err != nil {
+		return err
+	}
+	courseIDs, err := catalogCourseIDs(ctx, course)
+	if err != nil {
+		return err
+	}
+
+	refreshedAt := time.Now()
+	session := section.Academic_session.Name
+	professorIDs := section.Professors
+	if professorIDs == nil {
+		professorIDs = []primitive.ObjectID{}
+	}
+
+	courseMatch := bson.D{
+		{Key: "course_reference", Value: bson.D{{Key: "$in", Value: courseIDs}}},
+		{Key: "academic_session.name", Value: session},
+	}
+	professorMatch := bson.D{
+		{Key: "professors", Value: bson.D{{Key: "$in", Value: professorIDs}}},
+		{Key: "academic_session.name", Value: session},
+	}
+	if err = mergeGradeAggregates(ctx, schema.GRADE_AGGREGATE_COURSE, courseMatch, nil, refreshedAt); err != nil {
+		return err
+	}
+	if err = mergeGradeAggregates(ctx, schema.GRADE_AGGREGATE_COURSE_PROFESSOR, courseMatch, nil, refreshedAt); err != nil {
+		return err
+	}
+	if err = mergeGradeAggregates(ctx, schema.GRADE_AGGREGATE_PROFESSOR, professorMatch, professorIDs, refreshedAt); err != nil {
+		return err
+	}
+
+	// Aggregates that weren't recomputed have lost their last section with grades
+	_, err = gradeAggregateCollection.DeleteMany(ctx, bson.D{
+		{Key: "academic_session", Value: session},
+		{Key: "refreshed_at", Value: bson.D{{Key: "$lt", Value: refreshedAt}}},
+		{Key: "$or", Value: bson.A{
+			bson.D{
+				{Key: "kind", Value: bson.D{{Key: "$in", Value: bson.A{schema.GRADE_AGGREGATE_COURSE, schema.GRADE_AGGREGATE_COURSE_PROFESSOR}}}},
+				{Key: "subject_prefix", Value: course.Subject_prefix},
+				{Key: "course_number", Value: course.Course_number},
+			},
+			bson.D{
+				{Key: "kind", Value: schema.GRADE_AGGREGATE_PROFESSOR},
+				{Key: "professor_reference", Value: bson.D{{Key: "$in", Value: professorIDs}}},
+			},
+		}},
+	})
+	return err
+}
+
+// mergeGradeAggregates computes the aggregates of one kind from the sections matching sectionMatch and merges them into grade_aggregates,
+// replacing the existing aggregates with the same keys. For the kinds keyed by professor, professorIDs limits the aggregates computed to those
+// professors when it isn't nil, since the matching sections can have other professors whose aggregates would be incomplete.
+func mergeGradeAggregates(ctx context.Context, kind string, sectionMatch bson.D, professorIDs []primitive.ObjectID, refreshedAt time.Time) error {
+	pipeline := mongo.Pipeline{bson.D{{Key: "$match", Value: sectionMatch}}}
+	pipeline = append(pipeline, validGradesStages()...)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$match", Value: bson.D{
+			{Key: "grade_distribution.0", Value: bson.D{{Key: "$exists", Value: true}}},
+			{Key: "academic_session.name", Value: bson.D{{Key: "$exists", Value: true}}},
+		}}},
+		bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "courses"},
+			{Key: "localField", Value: "course_reference"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "course"},
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$course"}}}},
+	)
+
+	key := bson.D{{Key: "kind", Value: kind}}
+	professors := interface{}(bson.D{{Key: "$reduce", Value: bson.D{
+		{Key: "input", Value: "$professors"},
+		{Key: "initialValue", Value: bson.A{}},
+		{Key: "in", Value: bson.D{{Key: "$setUnion", Value: bson.A{"$$value", bson.D{{Key: "$ifNull", Value: bson.A{"$$this", bson.A{}}}}}}}},
+	}}})
+
+	if kind != schema.GRADE_AGGREGATE_PROFESSOR {
+		key = append(key,
+			bson.E{Key: "subject_prefix", Value: "$course.subject_prefix"},
+			bson.E{Key: "course_number", Value: "$course.course_number"},
+		)
+	}
+	if kind != schema.GRADE_AGGREGATE_COURSE {
+		// Every professor of a section gets the section's grades
+		pipeline = append(pipeline, bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$professors"}}}})
+		if professorIDs != nil {
+			pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.D{{Key: "professors", Value: bson.D{{Key: "$in", Value: professorIDs}}}}}})
+		}
+		key = append(key, bson.E{Key: "professor_reference", Value: "$professors"})
+		professors = bson.A{"$_id.professor_reference"}
+	}
+	key = append(key, bson.E{Key: "academic_session", Value: "$academic_session.name"})
+
+	// The distributions are all in the canonical layout by now, so they can be summed position by position
+	zeros := bson.A{}
+	for range schema.GradeLabels {
+		zeros = append(zeros, 0)
+	}
+	sumDistributions := bson.D{{Key: "$reduce", Value: bson.D{
+		{Key: "input", Value: "$grade_distributions"},
+		{Key: "initialValue", Value: zeros},
+		{Key: "in", Value: bson.D{{Key: "$map", Value: bson.D{
+			{Key: "input", Value: bson.D{{Key: "$range", Value: bson.A{0, len(schema.GradeLabels)}}}},
+			{Key: "as", Value: "i"},
+			{Key: "in", Value: bson.D{{Key: "$add", Value: bson.A{
+				bson.D{{Key: "$arrayElemAt", Value: bson.A{"$$value", "$$i"}}},
+				bson.D{{Key: "$arrayElemAt", Value: bson.A{"$$this", "$$i"}}},
+			}}}},
+		}}}},
+	}}}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: key},
+			{Key: "grade_distributions", Value: bson.D{{Key: "$push", Value: "$grade_distribution"}}},
+			{Key: "sections", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "professors", Value: bson.D{{Key: "$push", Value: "$professors"}}},
+		}}},
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "kind", Value: "$_id.kind"},
+			{Key: "subject_prefix", Value: "$_id.subject_prefix"},
+			{Key: "course_number", Value: "$_id.course_number"},
+			{Key: "professor_reference", Value: "$_id.professor_reference"},
+			{Key: "academic_session", Value: "$_id.academic_session"},
+			{Key: "grade_distribution", Value: sumDistributions},
+			{Key: "sections", Value: 1},
+			{Key: "professors", Value: professors},
+			{Key: "refreshed_at", Value: refreshedAt},
+		}}},
+		bson.D{{Key: "$merge", Value: bson.D{
+			{Key: "into", Value: "grade_aggregates"},
+			{Key: "on", Value: "_id"},
+			{Key: "whenMatched", Value: "replace"},
+			{Key: "whenNotMatched", Value: "insert"},
+		}}},
+	)
+
+	cursor, err := sectionCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
+
+// semesterGrades aggregates the grade distributions of a resolved grade query by semester. It reads the precomputed aggregates when they can
+// answer the query, and runs the live pipeline when they can't or fresh is set.
+func semesterGrades(ctx context.Context, query gradeQuery, fresh bool) ([]semesterGradeTotals, error) {
+	if !fresh {
+		grades, ok, err := storedSemesterGrades(ctx, query)
+		if err != nil || ok {
+			return grades, err
+		}
+	}
+	return aggregateSemesterGrades(ctx, query)
+}
+
+// storedSemesterGrades answers a resolved grade query from the precomputed aggregates by summing the aggregates of each semester. It returns
+// false when the aggregates haven't been built yet or the query filters on something they aren't keyed by.
+func storedSemesterGrades(ctx context.Context, query gradeQuery) ([]semesterGradeTotals, bool, error) {
+	filters := query.filters
+	if !gradeAggregatesReady.Load() || filters.School != "" || filters.Level != "" || filters.Section_number != "" || filters.Core_flag != "" {
+		return nil, false, nil
+	}
+
+	var aggregates []schema.GradeAggregate
+
+	find := bson.D{}
+	switch {
+	case query.hasCourseFilter() && query.hasProfessorFilter():
+		find = append(find, bson.E{Key: "kind", Value: schema.GRADE_AGGREGATE_COURSE_PROFESSOR})
+	case query.hasCourseFilter():
+		find = append(find, bson.E{Key: "kind", Value: schema.GRADE_AGGREGATE_COURSE})
+	case query.hasProfessorFilter():
+		find = append(find, bson.E{Key: "kind", Value: schema.GRADE_AGGREGATE_PROFESSOR})
+	default:
+		return nil, false, nil
+	}
+	if filters.Prefix != "" {
+		find = append(find, bson.E{Key: "subject_prefix", Value: filters.Prefix})
+	}
+	if filters.Number != "" {
+		find = append(find, bson.E{Key: "course_number", Value: filters.Number})
+	}
+	if query.hasProfessorFilter() {
+		find = append(find, bson.E{Key: "professor_reference", Value: bson.D{{Key: "$in", Value: query.professorIDs}}})
+	}
+	if query.sessionFilter != nil {
+		find = append(find, bson.E{Key: "academic_session", Value: query.sessionFilter})
+	}
+
+	cursor, err := gradeAggregateCollection.Find(ctx, find)
+	if err != nil {
+		return nil, false, err
+	}
+	if err = cursor.All(ctx, &aggregates); err != nil {
+		return nil, false, err
+	}
+
+	// A prefix alone matches the aggregates of many courses, which are summed into their semesters
+	grades := []semesterGradeTotals{}
+	semesters := make(map[string]int)
+	for _, aggregate := range aggregates {
+		i, ok := semesters[aggregate.Academic_session]
+		if !ok {
+			i = len(grades)
+			semesters[aggregate.Academic_session] = i
+			grades = append(grades, semesterGradeTotals{
+				Academic_session:   aggregate.Academic_session,
+				Grade_distribution: make([]int, len(schema.GradeLabels)),
+			})
+		}
+
+		for bucket, count := range schema.NewGradeDistribution(aggregate.Grade_distribution).Counts() {
+			grades[i].Grade_distribution[bucket] += count
+		}
+		grades[i].Sections += aggregate.Sections
+		for _, professor := range aggregate.Professors {
+			if !containsObjectID(grades[i].Professors, professor) {
+				grades[i].Professors = append(grades[i].Professors, professor)
+			}
+		}
+	}
+	return grades, true, nil
+}
+
+// containsObjectID reports whether ids contains id.
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
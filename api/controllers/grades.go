@@ -3,63 +3,39 @@ package controllers
 import (
 	"context"
 	"net/http"
+	"sort"
 	"time"
 
+	"github.com/UTDNebula/nebula-api/api/common/log"
 	"github.com/UTDNebula/nebula-api/api/responses"
+	"github.com/UTDNebula/nebula-api/api/schema"
 
 	"github.com/gin-gonic/gin"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// We want to Filter (Match) ASAP
-
-// --------------------------------------------------------
-
-// Aggregate By Course -> Section:
-// ---- Prefix
-// ---- Prefix, Number
-// ---- Prefix, Number, SectionNumber
-
-// Aggregate By Professor -> Section
-// ---- Professor
-
-// Aggregate By Find Course, Find Professor: then Match Section
-// ---- Prefix, Professor
-// ---- Prefix, Number, Professor
-// ---- Prefix, Number, Professor, SectionNumber
-
-// --------------------------------------------------------
-
-// Filter on Course
-// ---- Prefix
-// ---- Prefix, Number
-
-// Filter on Course then Section
-// ---- Prefix, Number, SectionNumber
-
-// Filter on Professor
-// ---- Professor
-
-// Filter on Section by Matching Course and Professor IDs
-// ---- Prefix, Professor
-// ---- Prefix, Number, Professor
-// ---- Prefix, Number, Professor, SectionNumber
-
-// 4 Functions
-
 // @Id gradeAggregationBySemester
 // @Router /grades/semester [get]
-// @Description "Returns grade distributions aggregated by semester"
+// @Description "Returns grade distributions and statistics aggregated by semester"
 // @Produce json
 // @Param prefix query string false "The course's subject prefix"
 // @Param number query string false "The course's official number"
 // @Param first_name query string false "The professor's first name"
 // @Param last_name query string false "The professors's last name"
+// @Param professor_id query string false "The professor's id, which takes precedence over the professor's name"
 // @Param section_number query string false "The number of the section"
-// @Success 200 {array} responses.GradeResponse "An array of grade distributions for each semester included"
+// @Param school query string false "The school offering the course"
+// @Param level query string false "The course level: lower, upper or graduate"
+// @Param core_flag query string false "A core requirement code the section fulfills, e.g. 020"
+// @Param term query string false "Only include this academic session, e.g. 23F"
+// @Param from query string false "Only include academic sessions from this one onwards, e.g. 21F"
+// @Param to query string false "Only include academic sessions up to and including this one, e.g. 23S"
+// @Param exclude_summer query boolean false "Leave out summer academic sessions"
+// @Param fresh query boolean false "Run the live aggregation instead of reading the precomputed grade aggregates"
+// @Success 200 {array} schema.SemesterGrades "An array of grade distributions and statistics for each semester included"
+// @Response 300 {array} schema.ProfessorCandidate "The professors sharing the given name, when more than one matches"
 func GradeAggregationSemester() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		gradesAggregation("semester", c)
@@ -68,267 +44,289 @@ func GradeAggregationSemester() gin.HandlerFunc {
 
 // @Id gradeAggregationOverall
 // @Router /grades/overall [get]
-// @Description "Returns the overall grade distribution"
+// @Description "Returns the overall grade distribution and statistics"
 // @Produce json
 // @Param prefix query string false "The course's subject prefix"
 // @Param number query string false "The course's official number"
 // @Param first_name query string false "The professor's first name"
 // @Param last_name query string false "The professors's last name"
+// @Param professor_id query string false "The professor's id, which takes precedence over the professor's name"
 // @Param section_number query string false "The number of the section"
-// @Success 200 {array} integer "A grade distribution array"
+// @Param school query string false "The school offering the course"
+// @Param level query string false "The course level: lower, upper or graduate"
+// @Param core_flag query string false "A core requirement code the section fulfills, e.g. 020"
+// @Param term query string false "Only include this academic session, e.g. 23F"
+// @Param from query string false "Only include academic sessions from this one onwards, e.g. 21F"
+// @Param to query string false "Only include academic sessions up to and including this one, e.g. 23S"
+// @Param exclude_summer query boolean false "Leave out summer academic sessions"
+// @Param fresh query boolean false "Run the live aggregation instead of reading the precomputed grade aggregates"
+// @Success 200 {object} schema.GradeSummary "The combined grade distribution and its statistics"
+// @Response 300 {array} schema.ProfessorCandidate "The professors sharing the given name, when more than one matches"
 func GradesAggregationOverall() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		gradesAggregation("overall", c)
 	}
 }
 
-// gradesAggregation performs the aggregation of grade distributions based on the provided flag, which can either be "semester" or "overall".
-// The function uses MongoDB aggregations and queries to filter grade distributions by course, professor, and section, and returns the
-// results based on the requested aggregation type.
-//
-// Parameters:
-// - flag (string): The type of aggregation, either "semester" or "overall".
-// - c (*gin.Context): The HTTP context for handling request parameters and responses.
+// GradeTrend returns the mean GPA and DFW rate of each semester together with a linear trend fitted to each, flagging the semesters that stray
+// far from the trend. Semesters without any graded students are left out.
 //
-// Returns the grade distribution depending on type of flag
-func gradesAggregation(flag string, c *gin.Context) {
-	var grades []map[string]interface{}
-	var results []map[string]interface{}
+// @Id gradeTrend
+// @Router /grades/trend [get]
+// @Description "Returns the per-semester mean GPA and DFW rate with a fitted trend and the semesters that are outliers"
+// @Produce json
+// @Param prefix query string false "The course's subject prefix"
+// @Param number query string false "The course's official number"
+// @Param first_name query string false "The professor's first name"
+// @Param last_name query string false "The professors's last name"
+// @Param professor_id query string false "The professor's id, which takes precedence over the professor's name"
+// @Param section_number query string false "The number of the section"
+// @Param school query string false "The school offering the course"
+// @Param level query string false "The course level: lower, upper or graduate"
+// @Param core_flag query string false "A core requirement code the section fulfills, e.g. 020"
+// @Param term query string false "Only include this academic session, e.g. 23F"
+// @Param from query string false "Only include academic sessions from this one onwards, e.g. 21F"
+// @Param to query string false "Only include academic sessions up to and including this one, e.g. 23S"
+// @Param exclude_summer query boolean false "Leave out summer academic sessions"
+// @Param fresh query boolean false "Run the live aggregation instead of reading the precomputed grade aggregates"
+// @Success 200 {object} schema.GradeTrend "The per-semester statistics and their fitted trends"
+// @Response 300 {array} schema.ProfessorCandidate "The professors sharing the given name, when more than one matches"
+func GradeTrend(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	var cursor *mongo.Cursor
-	var collection *mongo.Collection
-	var pipeline mongo.Pipeline
+	grades, ok := queryGrades(ctx, c)
+	if !ok {
+		return
+	}
 
-	// MongoDB match filters for different fields (courses, professors, sections)
-	var sectionMatch bson.D
-	var courseMatch bson.D
-	var courseFind bson.D
-	var professorMatch bson.D
-	var professorFind bson.D
+	terms := []schema.TrendTerm{}
+	for _, sem := range grades {
+		if _, err := schema.ParseTerm(sem.Academic_session); err != nil {
+			continue
+		}
+		stats := schema.NewGradeDistribution(sem.Grade_distribution).Stats(sem.Sections)
+		if stats.Total_students == 0 {
+			continue
+		}
+		terms = append(terms, schema.TrendTerm{
+			Academic_session: sem.Academic_session,
+			Mean_gpa:         stats.Mean_gpa,
+			Dfw_rate:         stats.Dfw_rate,
+			Total_students:   stats.Total_students,
+			Sample_count:     stats.Sample_count,
+			Professors:       sem.Professors,
+		})
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		a, _ := schema.ParseTerm(terms[i].Academic_session)
+		b, _ := schema.ParseTerm(terms[j].Academic_session)
+		return a.Before(b)
+	})
 
-	var err error
+	c.JSON(http.StatusOK, responses.GradeResponse{Status: http.StatusOK, Message: "success", Data: schema.NewGradeTrend(terms)})
+}
 
+// GradeComparison aggregates the grades matching two sets of filters, such as two professors or two terms of a course, and tests whether
+// their distributions really differ: Welch's t-test on the mean GPA, a chi-square test on the whole distribution, and a Mann-Whitney U test
+// on the ordering of the grades.
+//
+// @Id gradeComparison
+// @Router /grades/compare [post]
+// @Description "Returns two grade distributions along with tests of whether they differ"
+// @Accept json
+// @Produce json
+// @Param filters body schema.GradeComparisonRequest true "The two sets of grade filters, a and b, which take the same fields as /grades/overall"
+// @Param fresh query boolean false "Run the live aggregation instead of reading the precomputed grade aggregates"
+// @Success 200 {object} schema.GradeComparison "Both grade distributions and the results of the tests"
+// @Response 300 {array} schema.ProfessorCandidate "The professors sharing the given name, when more than one matches"
+func GradeComparison(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// @TODO: Recommend forcing using first_name and last_name to ensure single professors per query.
-	// All professors sharing the name will be aggregated together in the current implementation
-	// Extract query parameters from the HTTP request (optional course or professor filters)
-	prefix := c.Query("prefix")
-	number := c.Query("number")
-	section_number := c.Query("section_number")
-	first_name := c.Query("first_name")
-	last_name := c.Query("last_name")
-
-	//	Check if a professor filter is provided (either first or last name)
-	professor := (first_name != "" || last_name != "")
-
-	lookupSectionsStage := bson.D{
-		{Key: "$lookup", Value: bson.D{
-			{Key: "from", Value: "sections"},
-			{Key: "localField", Value: "sections"},
-			{Key: "foreignField", Value: "_id"},
-			{Key: "as", Value: "sections"},
-		}},
+	var request schema.GradeComparisonRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
 	}
 
-	// Unwind the joined sections array to process each section individually
-	unwindSectionsStage := bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$sections"}}}}
-
-	// Project only the grade distribution and academic session name from the sections
-	projectGradeDistributionStage := bson.D{
-		{Key: "$project", Value: bson.D{
-			{Key: "_id", Value: "$sections.academic_session.name"},
-			{Key: "grade_distribution", Value: "$sections.grade_distribution"},
-		}},
+	gradesA, ok := runGradeQuery(ctx, c, request.A)
+	if !ok {
+		return
 	}
-
-	projectGradeDistributionWithSectionsStage := bson.D{
-		{Key: "$project", Value: bson.D{
-			{Key: "_id", Value: "$academic_session.name"},
-			{Key: "grade_distribution", Value: "$grade_distribution"},
-		}},
+	gradesB, ok := runGradeQuery(ctx, c, request.B)
+	if !ok {
+		return
 	}
 
-	// Additional MongoDB stages to aggregate, sort, and sum the grade distributions.
-	unwindGradeDistributionStage := bson.D{
-		{Key: "$unwind", Value: bson.D{
-			{Key: "path", Value: "$grade_distribution"},
-			{Key: "includeArrayIndex", Value: "ix"},
-		}},
-	}
+	comparison := schema.NewGradeComparison(overallGradeSummary(gradesA), overallGradeSummary(gradesB))
+	c.JSON(http.StatusOK, responses.GradeResponse{Status: http.StatusOK, Message: "success", Data: comparison})
+}
 
-	groupGradesStage := bson.D{
-		{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: bson.D{
-				{Key: "academic_session", Value: "$_id"},
-				{Key: "ix", Value: "$ix"},
-			}},
-			{Key: "grades", Value: bson.D{{Key: "$push", Value: "$grade_distribution"}}},
-		}},
-	}
+// gradesAggregation performs the aggregation of grade distributions based on the provided flag, which can either be "semester" or "overall".
+// The grade filters in the query parameters are planned into a MongoDB aggregation by the grade query planner, which accepts any combination
+// of course, professor, section, term, school and core flag filters.
+//
+// Parameters:
+// - flag (string): The type of aggregation, either "semester" or "overall".
+// - c (*gin.Context): The HTTP context for handling request parameters and responses.
+//
+// Returns the grade distribution depending on type of flag
+func gradesAggregation(flag string, c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	sortGradesStage := bson.D{
-		{Key: "$sort", Value: bson.D{
-			{Key: "_id.ix", Value: 1},
-			{Key: "_id", Value: 1},
-		}},
+	grades, ok := queryGrades(ctx, c)
+	if !ok {
+		return
 	}
 
-	sumGradesStage := bson.D{{Key: "$addFields", Value: bson.D{{Key: "grades", Value: bson.D{{Key: "$sum", Value: "$grades"}}}}}}
-
-	groupGradeDistributionStage := bson.D{
-		{Key: "$group", Value: bson.D{
-			{Key: "_id", Value: "$_id.academic_session"},
-			{Key: "grade_distribution", Value: bson.D{{Key: "$push", Value: "$grades"}}},
-		}},
-	}
-	switch {
-	case prefix != "" && number == "" && section_number == "" && !professor:
-		// Filter on Course prefix only
-		collection = courseCollection
-		courseMatch = bson.D{{Key: "$match", Value: bson.M{"subject_prefix": prefix}}}
-		pipeline = mongo.Pipeline{courseMatch, lookupSectionsStage, unwindSectionsStage, projectGradeDistributionStage, unwindGradeDistributionStage, groupGradesStage, sortGradesStage, sumGradesStage, groupGradeDistributionStage}
-
-	case prefix != "" && number != "" && section_number == "" && !professor:
-		// Filter on course prefix and number
-		collection = courseCollection
-		courseMatch := bson.D{{Key: "$match", Value: bson.M{"subject_prefix": prefix, "course_number": number}}}
-		pipeline = mongo.Pipeline{courseMatch, lookupSectionsStage, unwindSectionsStage, projectGradeDistributionStage, unwindGradeDistributionStage, groupGradesStage, sortGradesStage, sumGradesStage, groupGradeDistributionStage}
-
-	case prefix != "" && number != "" && section_number != "" && !professor:
-		// Filter on course prefix, number, and section number
-		collection = courseCollection
-		courseMatch := bson.D{{Key: "$match", Value: bson.M{"subject_prefix": prefix, "course_number": number}}}
-		sectionMatch := bson.D{{Key: "$match", Value: bson.M{"sections.section_number": section_number}}}
-		pipeline = mongo.Pipeline{courseMatch, lookupSectionsStage, unwindSectionsStage, sectionMatch, projectGradeDistributionStage, unwindGradeDistributionStage, groupGradesStage, sortGradesStage, sumGradesStage, groupGradeDistributionStage}
-
-	case prefix == "" && number == "" && section_number == "" && professor:
-		// Filter on professor only (first or last name)
-		collection = professorCollection
-
-		// Build professorMatch
-		if last_name == "" {
-			professorMatch = bson.D{{Key: "$match", Value: bson.M{"first_name": first_name}}}
-		} else if first_name == "" {
-			professorMatch = bson.D{{Key: "$match", Value: bson.M{"last_name": last_name}}}
-		} else {
-			professorMatch = bson.D{{Key: "$match", Value: bson.M{"first_name": first_name, "last_name": last_name}}}
+	if flag == "overall" {
+		overallResponse := overallGradeSummary(grades)
+		c.JSON(http.StatusOK, responses.GradeResponse{Status: http.StatusOK, Message: "success", Data: overallResponse})
+	} else if flag == "semester" {
+		semesterResponse := make([]schema.SemesterGrades, len(grades))
+		for i, sem := range grades {
+			semesterResponse[i] = schema.SemesterGrades{
+				Academic_session: sem.Academic_session,
+				GradeSummary:     schema.NewGradeSummary(schema.NewGradeDistribution(sem.Grade_distribution), sem.Sections),
+			}
 		}
+		c.JSON(http.StatusOK, responses.GradeResponse{Status: http.StatusOK, Message: "success", Data: semesterResponse})
+	} else {
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: "Endpoint broken"})
+	}
+}
 
-		// Build grades pipeline
-		pipeline = mongo.Pipeline{professorMatch, lookupSectionsStage, unwindSectionsStage, projectGradeDistributionStage, unwindGradeDistributionStage, groupGradesStage, sortGradesStage, sumGradesStage, groupGradeDistributionStage}
-
-	case prefix != "" && professor:
-		// Filter on Section by Matching Course and Professor IDs
-
-		// Here we get the valid course ids and professor ids
-		// and then we perform the grades aggregation against the sections collection,
-		// matching on the course_reference and professor
+// overallGradeSummary combines the grade distributions of every semester into one.
+func overallGradeSummary(grades []semesterGradeTotals) schema.GradeSummary {
+	overallDistribution := schema.NewGradeDistribution(nil)
+	sampleCount := 0
+	for _, sem := range grades {
+		overallDistribution.Add(schema.NewGradeDistribution(sem.Grade_distribution))
+		sampleCount += sem.Sections
+	}
+	return schema.NewGradeSummary(overallDistribution, sampleCount)
+}
 
-		var profIDs []primitive.ObjectID
-		var courseIDs []primitive.ObjectID
+// queryGrades reads the grade filters from the query parameters and aggregates the matching grade distributions by semester. When that fails,
+// or the professor name is ambiguous, it writes the response itself and returns false.
+func queryGrades(ctx context.Context, c *gin.Context) ([]semesterGradeTotals, bool) {
+	var filters schema.GradeFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return nil, false
+	}
 
-		collection = sectionCollection
+	return runGradeQuery(ctx, c, filters)
+}
 
-		// Find valid professor ids
-		if last_name == "" {
-			professorFind = bson.D{{Key: "first_name", Value: first_name}}
-		} else if first_name == "" {
-			professorFind = bson.D{{Key: "last_name", Value: last_name}}
-		} else {
-			professorFind = bson.D{{Key: "first_name", Value: first_name}, {Key: "last_name", Value: last_name}}
-		}
+// runGradeQuery validates and plans a set of grade filters and aggregates the matching grade distributions by semester, from the precomputed
+// aggregates unless the fresh query parameter is set. When that fails, or the professor name is ambiguous, it writes the response itself and
+// returns false.
+func runGradeQuery(ctx context.Context, c *gin.Context, filters schema.GradeFilters) ([]semesterGradeTotals, bool) {
+	query, ok := prepareGradeQuery(ctx, c, filters)
+	if !ok {
+		return nil, false
+	}
 
-		cursor, err = professorCollection.Find(ctx, professorFind)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.GradeResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
-			return
-		}
-		if err = cursor.All(ctx, &results); err != nil {
-			panic(err)
-		}
+	grades, err := semesterGrades(ctx, query, c.Query("fresh") == "true")
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return nil, false
+	}
+	return grades, true
+}
 
-		for _, prof := range results {
-			profID := prof["_id"].(primitive.ObjectID)
-			profIDs = append(profIDs, profID)
-		}
+// prepareGradeQuery validates a set of grade filters and resolves the professor's name. When that fails, or the professor name is ambiguous,
+// it writes the response itself and returns false.
+func prepareGradeQuery(ctx context.Context, c *gin.Context, filters schema.GradeFilters) (gradeQuery, bool) {
+	query, err := newGradeQuery(filters)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return query, false
+	}
 
-		// Get valid course ids based on the provided course prefix and/or number
-		if number == "" {
-			courseFind = bson.D{{Key: "subject_prefix", Value: prefix}}
-		} else {
-			courseFind = bson.D{{Key: "subject_prefix", Value: prefix}, {Key: "course_number", Value: number}}
-		}
+	// Professors sharing a name would silently be merged together, so have the client pick one by id instead
+	candidates, err := query.resolveProfessors(ctx)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return query, false
+	}
+	if candidates != nil {
+		c.JSON(http.StatusMultipleChoices, responses.GradeResponse{Status: http.StatusMultipleChoices, Message: "ambiguous professor", Data: candidates})
+		return query, false
+	}
 
-		// Perform MongoDB query to find matching courses
-		cursor, err = courseCollection.Find(ctx, courseFind)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, responses.GradeResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
-			return
-		}
-		if err = cursor.All(ctx, &results); err != nil {
-			panic(err)
-		}
+	return query, true
+}
 
-		// Extract course IDs from query results
-		for _, course := range results {
-			courseID := course["_id"].(primitive.ObjectID)
-			courseIDs = append(courseIDs, courseID)
-		}
+// GradesBySectionID returns the grade distribution of a single section along with its statistics.
+//
+// @Id gradesBySectionId
+// @Router /section/{id}/grades [get]
+// @Description "Returns the grade distribution and statistics of the section with given ID"
+// @Produce json
+// @Param id path string true "ID of the section to get grades for"
+// @Success 200 {object} schema.GradeSummary "The section's grade distribution and its statistics"
+func GradesBySectionID(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-		// Build sectionMatch
-		if section_number == "" {
-			sectionMatch =
-				bson.D{{Key: "$match", Value: bson.D{
-					{Key: "course_reference", Value: bson.D{{Key: "$in", Value: courseIDs}}},
-					{Key: "professors", Value: bson.D{{Key: "$in", Value: profIDs}}},
-				}}}
-		} else {
-			sectionMatch =
-				bson.D{{Key: "$match", Value: bson.D{
-					{Key: "course_reference", Value: bson.D{{Key: "$in", Value: courseIDs}}},
-					{Key: "professors", Value: bson.D{{Key: "$in", Value: profIDs}}},
-					{Key: "section_number", Value: section_number},
-				}}}
-		}
+	sectionId := c.Param("id")
 
-		// Build grades pipeline
-		pipeline = mongo.Pipeline{sectionMatch, projectGradeDistributionWithSectionsStage, unwindGradeDistributionStage, groupGradesStage, sortGradesStage, sumGradesStage, groupGradeDistributionStage}
+	var section schema.Section
 
-	default:
-		c.JSON(http.StatusBadRequest, responses.GradeResponse{Status: http.StatusBadRequest, Message: "error", Data: "Invalid query parameters."})
+	// parse object id from id parameter
+	objId, err := primitive.ObjectIDFromHex(sectionId)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
 		return
 	}
 
-	// peform aggregation
-	cursor, err = collection.Aggregate(ctx, pipeline)
+	// find and parse matching section
+	err = sectionCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&section)
 	if err != nil {
+		log.WriteError(err)
 		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
 		return
 	}
 
-	// retrieve and parse all valid documents
-	if err = cursor.All(ctx, &grades); err != nil {
-		panic(err)
+	// reject distributions that aren't in the canonical layout rather than misreport them
+	distribution, err := schema.ParseGradeDistribution(section.Grade_distribution)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, responses.ErrorResponse{Status: http.StatusUnprocessableEntity, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// a section without a grade distribution contributes no samples
+	sampleCount := 0
+	if len(section.Grade_distribution) > 0 {
+		sampleCount = 1
 	}
 
-	if flag == "overall" {
-		// combine all semester grade_distributions
-		overallResponse := make([]int32, 14)
-		for _, sem := range grades {
-			if len(sem["grade_distribution"].(primitive.A)) != 14 {
-				print("Length of Array: ")
-				println(len(sem["grade_distribution"].(primitive.A)))
-			}
-			for i, grade := range sem["grade_distribution"].(primitive.A) {
-				overallResponse[i] += grade.(int32)
-			}
+	// return result
+	summary := schema.NewGradeSummary(distribution, sampleCount)
+	c.JSON(http.StatusOK, responses.GradeResponse{Status: http.StatusOK, Message: "success", Data: summary})
+}
+
+// combineGradeDistributions adds together the grade_distribution arrays of several sections. Sections without a grade distribution, or with a
+// malformed one, are skipped and don't count towards the sample count.
+func combineGradeDistributions(distributions [][]int) schema.GradeSummary {
+	combined, sampleCount := schema.NewGradeDistribution(nil), 0
+	for _, counts := range distributions {
+		if len(counts) == 0 {
+			continue
 		}
-		c.JSON(http.StatusOK, responses.GradeResponse{Status: http.StatusOK, Message: "success", Data: overallResponse})
-	} else if flag == "semester" {
-		c.JSON(http.StatusOK, responses.GradeResponse{Status: http.StatusOK, Message: "success", Data: grades})
-	} else {
-		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: "Endpoint broken"})
+		distribution, err := schema.ParseGradeDistribution(counts)
+		if err != nil {
+			continue
+		}
+		combined.Add(distribution)
+		sampleCount++
 	}
+	return schema.NewGradeSummary(combined, sampleCount)
 }
@@ -0,0 +1,372 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The grade query planner turns any combination of grade filters into an aggregation pipeline. Every plan produces a stream of matching
+// section documents, so the stages that follow (grouping by semester, by professor, ...) are the same no matter where the plan starts.
+//
+// We want to Filter (Match) ASAP, so the plan starts from the most selective collection that has a filter on it:
+//
+//	Professor filter         -> professors, joining only the matching sections
+//	Course or school filter  -> courses, joining only the matching sections
+//	Section filters only     -> sections
+//
+// Filters on a collection other than the starting one are turned into conditions on the section documents, looking up ids where needed.
+
+// errNoGradeFilters is returned when a grade query has no filters at all, which would aggregate every section on record.
+var errNoGradeFilters = errors.New("at least one filter is required")
+
+// gradeQuery is a set of grade filters that has been validated, with the term filters turned into a condition on the academic session name.
+type gradeQuery struct {
+	filters       schema.GradeFilters
+	professorIDs  []primitive.ObjectID
+	sessionFilter interface{}
+}
+
+// newGradeQuery validates the filters of a grade query. It doesn't touch the database, so any error it returns is the client's.
+func newGradeQuery(filters schema.GradeFilters) (gradeQuery, error) {
+	query := gradeQuery{filters: filters}
+
+	if filters.Professor_id != "" {
+		profID, err := primitive.ObjectIDFromHex(filters.Professor_id)
+		if err != nil {
+			return query, err
+		}
+		query.professorIDs = []primitive.ObjectID{profID}
+	}
+
+	if filters.Level != "" {
+		if _, ok := schema.CourseLevels[filters.Level]; !ok {
+			return query, errors.New("unknown course level")
+		}
+	}
+
+	if filters.Core_flag != "" {
+		if _, ok := schema.LookupCoreArea(filters.Core_flag); !ok {
+			return query, errors.New("unknown core flag")
+		}
+	}
+
+	sessionFilter, err := gradeSessionFilter(filters)
+	if err != nil {
+		return query, err
+	}
+	query.sessionFilter = sessionFilter
+
+	if !query.hasCourseFilter() && !query.hasProfessorFilter() && !query.hasSectionFilter() {
+		return query, errNoGradeFilters
+	}
+
+	return query, nil
+}
+
+// hasCourseFilter reports whether the query filters on fields of the course.
+func (q gradeQuery) hasCourseFilter() bool {
+	return q.filters.Prefix != "" || q.filters.Number != "" || q.filters.School != "" || q.filters.Level != ""
+}
+
+// hasProfessorFilter reports whether the query filters on the professor, by id or by name.
+func (q gradeQuery) hasProfessorFilter() bool {
+	return q.filters.Professor_id != "" || q.filters.First_name != "" || q.filters.Last_name != ""
+}
+
+// hasSectionFilter reports whether the query filters on fields of the section itself.
+func (q gradeQuery) hasSectionFilter() bool {
+	return q.filters.Section_number != "" || q.filters.Core_flag != "" || q.sessionFilter != nil
+}
+
+// resolveProfessors looks up the ids of the professors matching the query's professor name. When more than one professor shares the name, the
+// query is left unresolved and the candidates are returned so the client can pick one by id instead of having them silently merged.
+func (q *gradeQuery) resolveProfessors(ctx context.Context) ([]schema.ProfessorCandidate, error) {
+	if q.professorIDs != nil || !q.hasProfessorFilter() {
+		return nil, nil
+	}
+
+	var candidates []schema.ProfessorCandidate
+
+	professorFind := bson.D{}
+	if q.filters.First_name != "" {
+		professorFind = append(professorFind, bson.E{Key: "first_name", Value: q.filters.First_name})
+	}
+	if q.filters.Last_name != "" {
+		professorFind = append(professorFind, bson.E{Key: "last_name", Value: q.filters.Last_name})
+	}
+
+	cursor, err := professorCollection.Find(ctx, professorFind)
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	if len(candidates) > 1 {
+		return candidates, nil
+	}
+
+	// An unknown name resolves to no professors, which matches no sections
+	q.professorIDs = []primitive.ObjectID{}
+	for _, candidate := range candidates {
+		q.professorIDs = append(q.professorIDs, candidate.Id)
+	}
+	return nil, nil
+}
+
+// courseConditions returns the conditions on course documents.
+func (q gradeQuery) courseConditions() bson.D {
+	conditions := bson.D{}
+	if q.filters.Prefix != "" {
+		conditions = append(conditions, bson.E{Key: "subject_prefix", Value: q.filters.Prefix})
+	}
+	if q.filters.Number != "" {
+		conditions = append(conditions, bson.E{Key: "course_number", Value: q.filters.Number})
+	} else if q.filters.Level != "" {
+		conditions = append(conditions, bson.E{Key: "course_number", Value: primitive.Regex{Pattern: schema.CourseLevels[q.filters.Level]}})
+	}
+	if q.filters.School != "" {
+		conditions = append(conditions, bson.E{Key: "school", Value: q.filters.School})
+	}
+	return conditions
+}
+
+// sectionConditions returns the conditions on section documents that the section's own fields can answer.
+func (q gradeQuery) sectionConditions() bson.D {
+	conditions := bson.D{}
+	if q.filters.Section_number != "" {
+		conditions = append(conditions, bson.E{Key: "section_number", Value: q.filters.Section_number})
+	}
+	if q.filters.Core_flag != "" {
+		conditions = append(conditions, bson.E{Key: "core_flags", Value: q.filters.Core_flag})
+	}
+	if q.sessionFilter != nil {
+		conditions = append(conditions, bson.E{Key: "academic_session.name", Value: q.sessionFilter})
+	}
+	return conditions
+}
+
+// sectionPipeline plans the query, returning the collection to aggregate and a pipeline producing every matching section document. Professor
+// names must already be resolved with resolveProfessors.
+func (q gradeQuery) sectionPipeline(ctx context.Context) (*mongo.Collection, mongo.Pipeline, error) {
+	sectionConditions := q.sectionConditions()
+
+	switch {
+	case q.hasProfessorFilter():
+		// Course filters become a condition on the section's course reference
+		if q.hasCourseFilter() {
+			courseIDs, err := q.courseIDs(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			sectionConditions = append(sectionConditions, bson.E{Key: "course_reference", Value: bson.D{{Key: "$in", Value: courseIDs}}})
+		}
+		professorMatch := bson.D{{Key: "$match", Value: bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: q.professorIDs}}}}}}
+		return professorCollection, append(mongo.Pipeline{professorMatch}, joinSectionsStages(sectionConditions)...), nil
+
+	case q.hasCourseFilter():
+		courseMatch := bson.D{{Key: "$match", Value: q.courseConditions()}}
+		return courseCollection, append(mongo.Pipeline{courseMatch}, joinSectionsStages(sectionConditions)...), nil
+
+	default:
+		sectionMatch := bson.D{{Key: "$match", Value: sectionConditions}}
+		return sectionCollection, mongo.Pipeline{sectionMatch}, nil
+	}
+}
+
+// courseIDs finds the ids of every course matching the query's course filters.
+func (q gradeQuery) courseIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	var courses []schema.Course
+
+	cursor, err := courseCollection.Find(ctx, q.courseConditions(), options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(ctx, &courses); err != nil {
+		return nil, err
+	}
+
+	courseIDs := make([]primitive.ObjectID, len(courses))
+	for i, course := range courses {
+		courseIDs[i] = course.Id
+	}
+	return courseIDs, nil
+}
+
+// joinSectionsStages joins the sections referenced by a course or professor document, keeping only those matching the conditions, and
+// replaces each document with one of its sections.
+func joinSectionsStages(sectionConditions bson.D) mongo.Pipeline {
+	lookupSections := bson.D{
+		{Key: "from", Value: "sections"},
+		{Key: "localField", Value: "sections"},
+		{Key: "foreignField", Value: "_id"},
+		{Key: "as", Value: "sections"},
+	}
+	if len(sectionConditions) > 0 {
+		// Filter the sections while joining, before any grades are unwound
+		lookupSections = append(lookupSections, bson.E{Key: "pipeline", Value: mongo.Pipeline{
+			bson.D{{Key: "$match", Value: sectionConditions}},
+		}})
+	}
+
+	return mongo.Pipeline{
+		bson.D{{Key: "$lookup", Value: lookupSections}},
+		bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$sections"}}}},
+		bson.D{{Key: "$replaceRoot", Value: bson.D{{Key: "newRoot", Value: "$sections"}}}},
+	}
+}
+
+// semesterGradesStages sums the grade distributions of a stream of section documents per academic session. Each resulting document has the
+// academic session name as its _id, the summed grade_distribution array, the number of sections with grades as sections, and the ids of the
+// professors who taught those sections as professors.
+func semesterGradesStages() mongo.Pipeline {
+	return append(validGradesStages(), mongo.Pipeline{
+		// Project only the grade distribution, professors and academic session name from the sections
+		bson.D{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: "$academic_session.name"},
+			{Key: "grade_distribution", Value: "$grade_distribution"},
+			{Key: "professors", Value: "$professors"},
+		}}},
+		// Sum each bucket of the grade distributions separately
+		bson.D{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$grade_distribution"},
+			{Key: "includeArrayIndex", Value: "ix"},
+		}}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "academic_session", Value: "$_id"},
+				{Key: "ix", Value: "$ix"},
+			}},
+			{Key: "grades", Value: bson.D{{Key: "$push", Value: "$grade_distribution"}}},
+			{Key: "sections", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "professors", Value: bson.D{{Key: "$push", Value: "$professors"}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "_id.ix", Value: 1},
+			{Key: "_id", Value: 1},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.D{{Key: "grades", Value: bson.D{{Key: "$sum", Value: "$grades"}}}}}},
+		// Reassemble the buckets into one array per academic session
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$_id.academic_session"},
+			{Key: "grade_distribution", Value: bson.D{{Key: "$push", Value: "$grades"}}},
+			{Key: "sections", Value: bson.D{{Key: "$max", Value: "$sections"}}},
+			// Every section with grades has a first bucket, so its group has the professors of all of them
+			{Key: "professors", Value: bson.D{{Key: "$first", Value: "$professors"}}},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.D{{Key: "professors", Value: bson.D{{Key: "$reduce", Value: bson.D{
+			{Key: "input", Value: "$professors"},
+			{Key: "initialValue", Value: bson.A{}},
+			{Key: "in", Value: bson.D{{Key: "$setUnion", Value: bson.A{"$$value", bson.D{{Key: "$ifNull", Value: bson.A{"$$this", bson.A{}}}}}}}},
+		}}}}}}},
+	}...)
+}
+
+// validGradesStages drops the section documents of a stream whose grade_distribution array has a negative count, and empties the array of those
+// whose array isn't in the canonical layout, which leaves both out of the sums in the same way as schema.ParseGradeDistribution.
+func validGradesStages() mongo.Pipeline {
+	return mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "grade_distribution", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$elemMatch", Value: bson.D{{Key: "$lt", Value: 0}}}}}}}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "grade_distribution", Value: bson.D{{Key: "$let", Value: bson.D{
+			{Key: "vars", Value: bson.D{{Key: "size", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$isArray", Value: "$grade_distribution"}},
+				bson.D{{Key: "$size", Value: "$grade_distribution"}},
+				0,
+			}}}}}},
+			{Key: "in", Value: bson.D{{Key: "$cond", Value: bson.A{
+				bson.D{{Key: "$eq", Value: bson.A{"$$size", len(schema.GradeLabels)}}},
+				"$grade_distribution",
+				bson.A{},
+			}}}},
+		}}}}}}},
+	}
+}
+
+// semesterGradeTotals is the summed grade distribution of one academic session, as produced by semesterGradesStages.
+type semesterGradeTotals struct {
+	Academic_session   string               `bson:"_id"`
+	Grade_distribution []int                `bson:"grade_distribution"`
+	Sections           int                  `bson:"sections"`
+	Professors         []primitive.ObjectID `bson:"professors"`
+}
+
+// aggregateSemesterGrades runs a resolved grade query, returning the summed grade distribution of each academic session.
+func aggregateSemesterGrades(ctx context.Context, query gradeQuery) ([]semesterGradeTotals, error) {
+	var grades []semesterGradeTotals
+
+	collection, pipeline, err := query.sectionPipeline(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pipeline = append(pipeline, semesterGradesStages()...)
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(ctx, &grades); err != nil {
+		return nil, err
+	}
+	return grades, nil
+}
+
+// The earliest academic session assumed to have grade data, used as the start of a range given only an end.
+var earliestGradeTerm = schema.Term{Year: 2000, Season: schema.SPRING}
+
+// gradeSessionFilter builds a MongoDB condition on the academic session name from the term, from, to and exclude_summer filters.
+// It returns nil when the filters don't restrict the academic sessions at all. term selects a single academic session and can't be
+// combined with from or to, which select an inclusive range that is open-ended when either is left out. from can't be after to.
+func gradeSessionFilter(filters schema.GradeFilters) (interface{}, error) {
+	term, from, to, excludeSummer := filters.Term, filters.From, filters.To, filters.Exclude_summer
+
+	if term != "" && (from != "" || to != "") {
+		return nil, errors.New("term can't be combined with from or to")
+	}
+
+	if term == "" && from == "" && to == "" {
+		if excludeSummer {
+			return bson.D{{Key: "$not", Value: primitive.Regex{Pattern: string(schema.SUMMER) + "$"}}}, nil
+		}
+		return nil, nil
+	}
+
+	// Ranges are expanded into the list of session names they cover, since session names don't sort chronologically
+	start, end := earliestGradeTerm, schema.TermForDate(time.Now()).Next()
+	var err error
+	if term != "" {
+		if start, err = schema.ParseTerm(term); err != nil {
+			return nil, err
+		}
+		end = start
+	}
+	if from != "" {
+		if start, err = schema.ParseTerm(from); err != nil {
+			return nil, err
+		}
+	}
+	if to != "" {
+		if end, err = schema.ParseTerm(to); err != nil {
+			return nil, err
+		}
+	}
+	if from != "" && to != "" && end.Before(start) {
+		return nil, errors.New("from must not be after to")
+	}
+
+	sessions := bson.A{}
+	for _, t := range schema.TermRange(start, end) {
+		if !excludeSummer || t.Season != schema.SUMMER {
+			sessions = append(sessions, t.String())
+		}
+	}
+	return bson.D{{Key: "$in", Value: sessions}}, nil
+}
@@ -0,0 +1,514 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/responses"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// How long a computed leaderboard is reused before it's aggregated again. Grades only change when a new semester is published.
+const rankingCacheTTL = time.Hour
+
+// Default minimum-sample guards, so leaderboards aren't topped by a single small section.
+const (
+	defaultRankingMinStudents = 20
+	defaultRankingMinSections = 1
+)
+
+// The most leaderboards kept in the cache at once. When it's full, the leaderboard closest to expiring makes room for the new one.
+const rankingCacheMaxEntries = 256
+
+type rankingCacheEntry struct {
+	rankings []schema.GradeRanking
+	expires  time.Time
+}
+
+// Leaderboards are cached by their validated parameters (without the offset), so paging through one only aggregates it once.
+var rankingCache = make(map[string]rankingCacheEntry)
+var rankingCacheMutex sync.Mutex
+
+// GradeRankings ranks courses, or the professors of courses, by a grade statistic. Entries with fewer students or sections than the
+// minimum-sample guards are left out. Like the other grade endpoints, leaderboards are built from the precomputed grade aggregates when they
+// can answer the filters, and from the live pipeline when they can't or fresh is set.
+//
+// @Id gradeRankings
+// @Router /grades/rankings [get]
+// @Description "Returns courses or professors ranked by a grade statistic"
+// @Produce json
+// @Param by query string false "What to rank: course (default) or professor. Ranking professors requires a course filter"
+// @Param metric query string false "The statistic to rank by: mean_gpa (default), percent_a, pass_rate, dfw_rate, total_students or sample_count"
+// @Param order query string false "asc or desc, defaults to asc for dfw_rate and desc otherwise"
+// @Param min_students query integer false "Leave out entries with fewer students than this, defaults to 20"
+// @Param min_sections query integer false "Leave out entries with fewer sections than this, defaults to 1"
+// @Param offset query integer false "The number of entries to skip"
+// @Param fresh query boolean false "Aggregate the sections directly instead of reading the precomputed aggregates"
+// @Param prefix query string false "The course's subject prefix"
+// @Param number query string false "The course's official number"
+// @Param school query string false "The school offering the course"
+// @Param level query string false "The course level: lower, upper or graduate"
+// @Param first_name query string false "The professor's first name"
+// @Param last_name query string false "The professors's last name"
+// @Param professor_id query string false "The professor's id, which takes precedence over the professor's name"
+// @Param section_number query string false "The number of the section"
+// @Param core_flag query string false "A core requirement code the section fulfills, e.g. 020"
+// @Param term query string false "Only include this academic session, e.g. 23F"
+// @Param from query string false "Only include academic sessions from this one onwards, e.g. 21F"
+// @Param to query string false "Only include academic sessions up to and including this one, e.g. 23S"
+// @Param exclude_summer query boolean false "Leave out summer academic sessions"
+// @Success 200 {array} schema.GradeRanking "The ranked entries"
+// @Response 300 {array} schema.ProfessorCandidate "The professors sharing the given name, when more than one matches"
+func GradeRankings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	by := c.DefaultQuery("by", "course")
+	metric := c.DefaultQuery("metric", "mean_gpa")
+
+	var filters schema.GradeFilters
+	if err := c.ShouldBindQuery(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// Validate the ranking parameters before touching the database
+	if by != "course" && by != "professor" {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: "Invalid by parameter."})
+		return
+	}
+	if _, ok := (schema.GradeStats{}).Metric(metric); !ok {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: "Invalid metric parameter."})
+		return
+	}
+	descending := metric != "dfw_rate"
+	switch c.Query("order") {
+	case "":
+	case "asc":
+		descending = false
+	case "desc":
+		descending = true
+	default:
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: "Invalid order parameter."})
+		return
+	}
+	minStudents, err := intQuery(c, "min_students", defaultRankingMinStudents)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+	minSections, err := intQuery(c, "min_sections", defaultRankingMinSections)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+	offset, ok := offsetQuery(c)
+	if !ok {
+		return
+	}
+	fresh := c.Query("fresh") == "true"
+
+	// Serve the leaderboard from the cache when it was computed recently. Only the parameters read above make up the key, so unknown
+	// parameters can't add entries.
+	cacheKey := fmt.Sprintf("%s|%s|%t|%d|%d|%+v", by, metric, descending, minStudents, minSections, filters)
+
+	rankingCacheMutex.Lock()
+	entry, cached := rankingCache[cacheKey]
+	rankingCacheMutex.Unlock()
+
+	if fresh || !cached || time.Now().After(entry.expires) {
+		query, ok := prepareGradeQuery(ctx, c, filters)
+		if !ok {
+			return
+		}
+		if by == "professor" && !query.hasCourseFilter() {
+			c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: "Ranking professors requires a course filter."})
+			return
+		}
+
+		rankings, err := gradeRankings(ctx, query, by, fresh)
+		if err != nil {
+			log.WriteError(err)
+			c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+			return
+		}
+		entry = rankingCacheEntry{rankings: rankGrades(rankings, metric, descending, minStudents, minSections), expires: time.Now().Add(rankingCacheTTL)}
+		storeRanking(cacheKey, entry)
+	}
+
+	// Page through the leaderboard the same way as the search endpoints
+	rankings := []schema.GradeRanking{}
+	if offset < len(entry.rankings) {
+		end := min(offset+int(configs.GetEnvLimit()), len(entry.rankings))
+		rankings = entry.rankings[offset:end]
+	}
+
+	c.JSON(http.StatusOK, responses.GradeRankingsResponse{Status: http.StatusOK, Message: "success", Data: rankings})
+}
+
+// storeRanking caches a leaderboard, first dropping the expired ones and, if the cache is still full, the one closest to expiring.
+func storeRanking(key string, entry rankingCacheEntry) {
+	rankingCacheMutex.Lock()
+	defer rankingCacheMutex.Unlock()
+
+	now := time.Now()
+	for cachedKey, old := range rankingCache {
+		if now.After(old.expires) {
+			delete(rankingCache, cachedKey)
+		}
+	}
+	if _, replacing := rankingCache[key]; !replacing && len(rankingCache) >= rankingCacheMaxEntries {
+		oldestKey, oldest := "", time.Time{}
+		for cachedKey, old := range rankingCache {
+			if oldestKey == "" || old.expires.Before(oldest) {
+				oldestKey, oldest = cachedKey, old.expires
+			}
+		}
+		delete(rankingCache, oldestKey)
+	}
+	rankingCache[key] = entry
+}
+
+// gradeRankings combines the grade distributions of the sections matching a resolved query per course or per professor. It reads the
+// precomputed aggregates when they can answer the query, and runs the live pipeline when they can't or fresh is set.
+func gradeRankings(ctx context.Context, query gradeQuery, by string, fresh bool) ([]schema.GradeRanking, error) {
+	if !fresh {
+		rankings, ok, err := storedRankings(ctx, query, by)
+		if err != nil || ok {
+			return rankings, err
+		}
+	}
+	return aggregateRankings(ctx, query, by)
+}
+
+// storedRankings combines the precomputed aggregates matching a resolved query per course or per professor. Like storedSemesterGrades, it
+// returns false when the aggregates haven't been built yet or the query filters on something they aren't keyed by.
+func storedRankings(ctx context.Context, query gradeQuery, by string) ([]schema.GradeRanking, bool, error) {
+	filters := query.filters
+	if !gradeAggregatesReady.Load() || filters.School != "" || filters.Level != "" || filters.Section_number != "" || filters.Core_flag != "" {
+		return nil, false, nil
+	}
+
+	var aggregates []schema.GradeAggregate
+
+	// Professors are ranked from their aggregates for a course, and so are courses when only some professors' sections count
+	find := bson.D{{Key: "kind", Value: schema.GRADE_AGGREGATE_COURSE}}
+	if by == "professor" || query.hasProfessorFilter() {
+		find = bson.D{{Key: "kind", Value: schema.GRADE_AGGREGATE_COURSE_PROFESSOR}}
+	}
+	if filters.Prefix != "" {
+		find = append(find, bson.E{Key: "subject_prefix", Value: filters.Prefix})
+	}
+	if filters.Number != "" {
+		find = append(find, bson.E{Key: "course_number", Value: filters.Number})
+	}
+	if query.hasProfessorFilter() {
+		find = append(find, bson.E{Key: "professor_reference", Value: bson.D{{Key: "$in", Value: query.professorIDs}}})
+	}
+	if query.sessionFilter != nil {
+		find = append(find, bson.E{Key: "academic_session", Value: query.sessionFilter})
+	}
+
+	cursor, err := gradeAggregateCollection.Find(ctx, find)
+	if err != nil {
+		return nil, false, err
+	}
+	if err = cursor.All(ctx, &aggregates); err != nil {
+		return nil, false, err
+	}
+
+	// Sum the aggregates of every academic session (and, for courses, every professor) into one entry each
+	type rankingTotals struct {
+		ranking      schema.GradeRanking
+		distribution schema.GradeDistribution
+		sections     int
+	}
+	var order []string
+	totals := make(map[string]*rankingTotals)
+	for _, aggregate := range aggregates {
+		key := aggregate.Subject_prefix + " " + aggregate.Course_number
+		ranking := schema.GradeRanking{Subject_prefix: aggregate.Subject_prefix, Course_number: aggregate.Course_number}
+		if by == "professor" {
+			if aggregate.Professor_reference == nil {
+				continue
+			}
+			key = aggregate.Professor_reference.Hex()
+			ranking = schema.GradeRanking{Professor_reference: aggregate.Professor_reference}
+		}
+
+		total, ok := totals[key]
+		if !ok {
+			total = &rankingTotals{ranking: ranking, distribution: schema.NewGradeDistribution(nil)}
+			totals[key] = total
+			order = append(order, key)
+		}
+		total.distribution.Add(schema.NewGradeDistribution(aggregate.Grade_distribution))
+		total.sections += aggregate.Sections
+	}
+
+	rankings := make([]schema.GradeRanking, len(order))
+	for i, key := range order {
+		rankings[i] = totals[key].ranking
+		rankings[i].Grades = schema.NewGradeSummary(totals[key].distribution, totals[key].sections)
+	}
+
+	// The aggregates are keyed by reference, so look up the titles and names they are shown with
+	if by == "professor" {
+		err = nameRankedProfessors(ctx, rankings)
+	} else {
+		err = titleRankedCourses(ctx, rankings)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return rankings, true, nil
+}
+
+// titleRankedCourses fills in the title of each ranked course from its latest catalog year.
+func titleRankedCourses(ctx context.Context, rankings []schema.GradeRanking) error {
+	if len(rankings) == 0 {
+		return nil
+	}
+
+	var courses []schema.Course
+
+	prefixes := bson.A{}
+	for _, ranking := range rankings {
+		prefixes = append(prefixes, ranking.Subject_prefix)
+	}
+	cursor, err := courseCollection.Find(ctx, bson.M{"subject_prefix": bson.M{"$in": prefixes}}, options.Find().
+		SetProjection(bson.M{"subject_prefix": 1, "course_number": 1, "title": 1, "catalog_year": 1}).
+		SetSort(bson.D{{Key: "catalog_year", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	if err = cursor.All(ctx, &courses); err != nil {
+		return err
+	}
+
+	titles := make(map[string]string)
+	for _, course := range courses {
+		titles[course.Subject_prefix+" "+course.Course_number] = course.Title
+	}
+	for i := range rankings {
+		rankings[i].Title = titles[rankings[i].Subject_prefix+" "+rankings[i].Course_number]
+	}
+	return nil
+}
+
+// nameRankedProfessors fills in the name of each ranked professor.
+func nameRankedProfessors(ctx context.Context, rankings []schema.GradeRanking) error {
+	if len(rankings) == 0 {
+		return nil
+	}
+
+	var professors []schema.Professor
+
+	ids := bson.A{}
+	for _, ranking := range rankings {
+		ids = append(ids, *ranking.Professor_reference)
+	}
+	cursor, err := professorCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"first_name": 1, "last_name": 1}))
+	if err != nil {
+		return err
+	}
+	if err = cursor.All(ctx, &professors); err != nil {
+		return err
+	}
+
+	names := make(map[primitive.ObjectID]schema.Professor)
+	for _, professor := range professors {
+		names[professor.Id] = professor
+	}
+	for i := range rankings {
+		professor := names[*rankings[i].Professor_reference]
+		rankings[i].First_name, rankings[i].Last_name = professor.First_name, professor.Last_name
+	}
+	return nil
+}
+
+// aggregateRankings combines the grade distributions of the sections matching a query, either per course (across catalog years) or per professor.
+func aggregateRankings(ctx context.Context, query gradeQuery, by string) ([]schema.GradeRanking, error) {
+	collection, pipeline, err := query.sectionPipeline(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sections without grades can't be ranked
+	pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.D{{Key: "grade_distribution.0", Value: bson.D{{Key: "$exists", Value: true}}}}}})
+
+	var rankings []schema.GradeRanking
+
+	if by == "course" {
+		var results []struct {
+			Id struct {
+				Subject_prefix string `bson:"subject_prefix"`
+				Course_number  string `bson:"course_number"`
+			} `bson:"_id"`
+			Title               string    `bson:"title"`
+			Grade_distributions [][][]int `bson:"grade_distributions"`
+		}
+
+		// Group by course document first, then merge the catalog years of each course
+		pipeline = append(pipeline,
+			bson.D{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: "$course_reference"},
+				{Key: "grade_distributions", Value: bson.D{{Key: "$push", Value: "$grade_distribution"}}},
+			}}},
+			bson.D{{Key: "$lookup", Value: bson.D{
+				{Key: "from", Value: "courses"},
+				{Key: "localField", Value: "_id"},
+				{Key: "foreignField", Value: "_id"},
+				{Key: "as", Value: "course"},
+			}}},
+			bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$course"}}}},
+			bson.D{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: bson.D{
+					{Key: "subject_prefix", Value: "$course.subject_prefix"},
+					{Key: "course_number", Value: "$course.course_number"},
+				}},
+				{Key: "title", Value: bson.D{{Key: "$last", Value: "$course.title"}}},
+				{Key: "grade_distributions", Value: bson.D{{Key: "$push", Value: "$grade_distributions"}}},
+			}}},
+		)
+
+		if err = aggregateInto(ctx, collection, pipeline, &results); err != nil {
+			return nil, err
+		}
+
+		for _, result := range results {
+			var distributions [][]int
+			for _, catalogYear := range result.Grade_distributions {
+				distributions = append(distributions, catalogYear...)
+			}
+			rankings = append(rankings, schema.GradeRanking{
+				Subject_prefix: result.Id.Subject_prefix,
+				Course_number:  result.Id.Course_number,
+				Title:          result.Title,
+				Grades:         combineGradeDistributions(distributions),
+			})
+		}
+	} else {
+		var results []struct {
+			Id                  primitive.ObjectID `bson:"_id"`
+			First_name          string             `bson:"first_name"`
+			Last_name           string             `bson:"last_name"`
+			Grade_distributions [][]int            `bson:"grade_distributions"`
+		}
+
+		pipeline = append(pipeline,
+			bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$professors"}}}},
+			bson.D{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: "$professors"},
+				{Key: "grade_distributions", Value: bson.D{{Key: "$push", Value: "$grade_distribution"}}},
+			}}},
+			bson.D{{Key: "$lookup", Value: bson.D{
+				{Key: "from", Value: "professors"},
+				{Key: "localField", Value: "_id"},
+				{Key: "foreignField", Value: "_id"},
+				{Key: "as", Value: "professor"},
+			}}},
+			bson.D{{Key: "$unwind", Value: bson.D{
+				{Key: "path", Value: "$professor"},
+				{Key: "preserveNullAndEmptyArrays", Value: true},
+			}}},
+			bson.D{{Key: "$project", Value: bson.D{
+				{Key: "first_name", Value: "$professor.first_name"},
+				{Key: "last_name", Value: "$professor.last_name"},
+				{Key: "grade_distributions", Value: 1},
+			}}},
+		)
+
+		if err = aggregateInto(ctx, collection, pipeline, &results); err != nil {
+			return nil, err
+		}
+
+		for _, result := range results {
+			professorID := result.Id
+			rankings = append(rankings, schema.GradeRanking{
+				Professor_reference: &professorID,
+				First_name:          result.First_name,
+				Last_name:           result.Last_name,
+				Grades:              combineGradeDistributions(result.Grade_distributions),
+			})
+		}
+	}
+
+	return rankings, nil
+}
+
+// rankGrades drops the entries below the minimum-sample guards, then sorts the rest by the metric and assigns their ranks. Entries with equal
+// values share a rank, and the entry after them skips ahead (1, 2, 2, 4).
+func rankGrades(rankings []schema.GradeRanking, metric string, descending bool, minStudents int, minSections int) []schema.GradeRanking {
+	ranked := []schema.GradeRanking{}
+	for _, ranking := range rankings {
+		if ranking.Grades.Stats.Total_students >= minStudents && ranking.Grades.Stats.Sample_count >= minSections {
+			ranking.Value, _ = ranking.Grades.Stats.Metric(metric)
+			ranked = append(ranked, ranking)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if descending {
+			return ranked[i].Value > ranked[j].Value
+		}
+		return ranked[i].Value < ranked[j].Value
+	})
+
+	for i := range ranked {
+		if i > 0 && ranked[i].Value == ranked[i-1].Value {
+			ranked[i].Rank = ranked[i-1].Rank
+		} else {
+			ranked[i].Rank = i + 1
+		}
+	}
+	return ranked
+}
+
+// aggregateInto runs a pipeline against a collection and decodes every resulting document into results.
+func aggregateInto(ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline, results interface{}) error {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
+
+// offsetQuery parses the offset query parameter, which defaults to 0. When it isn't a non-negative integer, it writes a 400 response itself and
+// returns false.
+func offsetQuery(c *gin.Context) (int, bool) {
+	offset, err := intQuery(c, "offset", 0)
+	if err != nil {
+		log.WriteErrorWithMsg(err, log.OffsetNotTypeInteger)
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "Error offset is not type integer", Data: err.Error()})
+		return 0, false
+	}
+	if offset < 0 {
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: "The offset can't be negative."})
+		return 0, false
+	}
+	return offset, true
+}
+
+// intQuery parses an integer query parameter, returning the default when it's missing.
+func intQuery(c *gin.Context, key string, defaultValue int) (int, error) {
+	value := c.Query(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
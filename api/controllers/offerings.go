@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/responses"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CourseOfferingsById compares the catalog offering frequency of a course with the terms its sections actually ran in, and predicts the next
+// term it will be offered. Sections from every catalog year of the course are considered.
+//
+// @Id courseOfferingsById
+// @Router /course/{id}/offerings [get]
+// @Description "Returns the past terms, predicted next term and catalog mismatches for the course with given ID"
+// @Produce json
+// @Param id path string true "ID of the course to get offerings for"
+// @Success 200 {object} schema.CourseOfferings "The course's offering history and prediction"
+func CourseOfferingsById(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	courseId := c.Param("id")
+
+	var course schema.Course
+
+	// Parse object id from id parameter
+	objId, err := primitive.ObjectIDFromHex(courseId)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// Find and parse matching course
+	err = courseCollection.FindOne(ctx, bson.M{"_id": objId}).Decode(&course)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// Every catalog year of a course is its own document, so gather the ids of all of them
+	courseIDs, err := catalogCourseIDs(ctx, course)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// Find every term a section of the course was offered in
+	sessionNames, err := sectionCollection.Distinct(ctx, "academic_session.name", bson.M{"course_reference": bson.M{"$in": courseIDs}})
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	var pastTerms []schema.Term
+	for _, name := range sessionNames {
+		if name, ok := name.(string); ok {
+			if term, err := schema.ParseTerm(name); err == nil {
+				pastTerms = append(pastTerms, term)
+			}
+		}
+	}
+
+	offerings := schema.NewCourseOfferings(schema.ParseOfferingFrequency(course.Offering_frequency), pastTerms, schema.TermForDate(time.Now()))
+	offerings.Course_reference = course.Id
+	offerings.Subject_prefix = course.Subject_prefix
+	offerings.Course_number = course.Course_number
+
+	// Return result
+	c.JSON(http.StatusOK, responses.CourseOfferingsResponse{Status: http.StatusOK, Message: "success", Data: offerings})
+}
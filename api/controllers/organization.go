@@ -0,0 +1,210 @@
+// Package controllers handles the business logic of the API, including the student organization directory.
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/responses"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"github.com/gin-gonic/gin"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var organizationCollection *mongo.Collection = configs.GetCollection("organizations")
+
+// organizationProjection leaves out the picture data, which can be large, and only records whether there is a picture.
+var organizationProjection = bson.D{
+	{Key: "title", Value: 1},
+	{Key: "description", Value: 1},
+	{Key: "categories", Value: 1},
+	{Key: "president_name", Value: 1},
+	{Key: "emails", Value: 1},
+	{Key: "has_picture", Value: bson.D{{Key: "$gt", Value: bson.A{
+		bson.D{{Key: "$strLenBytes", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$picture_data", ""}}}}},
+		0,
+	}}}},
+}
+
+// @Id organizationSearch
+// @Router /organization [get]
+// @Description "Returns all organizations matching the search text and categories, ordered by title"
+// @Produce json
+// @Param q query string false "Text to search for in the organization's title and description"
+// @Param category query []string false "A category the organization must be in; repeat to require several" collectionFormat(multi)
+// @Param president_name query string false "The organization's president"
+// @Param offset query integer false "The starting position of the current page of organizations (e.g. For starting at the 17th organization, offset=16)."
+// @Success 200 {array} schema.Organization "A list of organizations"
+func OrganizationSearch(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+	var organizations []schema.Organization
+
+	defer cancel()
+
+	query := bson.M{}
+	if text := strings.TrimSpace(c.Query("q")); text != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(text), Options: "i"}
+		query["$or"] = bson.A{bson.M{"title": pattern}, bson.M{"description": pattern}}
+	}
+	if categories := c.QueryArray("category"); len(categories) > 0 {
+		query["categories"] = bson.M{"$all": categories}
+	}
+	if president := c.Query("president_name"); president != "" {
+		query["president_name"] = president
+	}
+
+	optionLimit, err := configs.GetOptionLimit(&query, c)
+	if err != nil {
+		log.WriteErrorWithMsg(err, log.OffsetNotTypeInteger)
+		c.JSON(http.StatusConflict, responses.ErrorResponse{Status: http.StatusConflict, Message: "Error offset is not type integer", Data: err.Error()})
+		return
+	}
+
+	// get cursor for query results
+	cursor, err := organizationCollection.Find(ctx, query, optionLimit.
+		SetProjection(organizationProjection).
+		SetSort(bson.D{{Key: "title", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// retrieve and parse all valid documents
+	if err = cursor.All(ctx, &organizations); err != nil {
+		log.WritePanic(err)
+		panic(err)
+	}
+	for i := range organizations {
+		organizations[i] = withPictureURI(organizations[i])
+	}
+
+	// return result
+	c.JSON(http.StatusOK, responses.MultiOrganizationResponse{Status: http.StatusOK, Message: "success", Data: organizations})
+}
+
+// @Id organizationById
+// @Router /organization/{id} [get]
+// @Description "Returns the organization with given ID"
+// @Produce json
+// @Param id path string true "ID of the organization to get"
+// @Success 200 {object} schema.Organization "An organization"
+func OrganizationById(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+	organizationId := c.Param("id")
+
+	var organization schema.Organization
+
+	defer cancel()
+
+	// parse object id from id parameter
+	objId, err := primitive.ObjectIDFromHex(organizationId)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// find and parse matching organization
+	err = organizationCollection.FindOne(ctx, bson.M{"_id": objId}, options.FindOne().SetProjection(organizationProjection)).Decode(&organization)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	// return result
+	c.JSON(http.StatusOK, responses.SingleOrganizationResponse{Status: http.StatusOK, Message: "success", Data: withPictureURI(organization)})
+}
+
+// OrganizationImage returns the decoded picture of an organization. The picture is stored as base64, optionally as a data URI declaring its
+// type; otherwise the type is detected from the image itself.
+//
+// @Id organizationImage
+// @Router /organization/{id}/image [get]
+// @Description "Returns the picture of the organization with given ID"
+// @Produce image/png,image/jpeg,image/gif,image/webp
+// @Param id path string true "ID of the organization to get the picture of"
+// @Success 200 {file} file "The organization's picture"
+// @Success 304 "The picture hasn't changed since the ETag in If-None-Match"
+func OrganizationImage(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var organization schema.Organization
+
+	// parse object id from id parameter
+	objId, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+		return
+	}
+
+	err = organizationCollection.FindOne(ctx, bson.M{"_id": objId}, options.FindOne().SetProjection(bson.M{"picture_data": 1})).Decode(&organization)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+	if organization.Picture_data == "" {
+		c.JSON(http.StatusNotFound, responses.ErrorResponse{Status: http.StatusNotFound, Message: "error", Data: "The organization has no picture."})
+		return
+	}
+
+	contentType, picture, err := decodePicture(organization.Picture_data)
+	if err != nil {
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+		return
+	}
+
+	etag := contentETag(picture)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=86400")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, contentType, picture)
+}
+
+// withPictureURI links an organization to its picture, if it has one.
+func withPictureURI(organization schema.Organization) schema.Organization {
+	if organization.Has_picture {
+		organization.Picture_uri = "/organization/" + organization.Id.Hex() + "/image"
+	}
+	return organization
+}
+
+// decodePicture decodes base64 picture data, which may be a data URI such as "data:image/png;base64,...", and returns it with its content type.
+func decodePicture(data string) (string, []byte, error) {
+	declaredType := ""
+	if header, encoded, ok := strings.Cut(data, ","); ok && strings.HasPrefix(header, "data:") {
+		declaredType, _, _ = mime.ParseMediaType(strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64"))
+		data = encoded
+	}
+
+	picture, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	if err != nil {
+		return "", nil, err
+	}
+	if declaredType == "" {
+		declaredType = http.DetectContentType(picture)
+	}
+	return declaredType, picture, nil
+}
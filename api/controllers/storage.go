@@ -0,0 +1,212 @@
+// Package controllers handles the business logic of the API, including the storage endpoints, which serve objects uploaded to named buckets.
+package controllers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/UTDNebula/nebula-api/api/common/blob"
+	"github.com/UTDNebula/nebula-api/api/common/log"
+	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/responses"
+	"github.com/UTDNebula/nebula-api/api/schema"
+
+	"github.com/gin-gonic/gin"
+)
+
+// blobStore holds the objects served by the storage endpoints, kept in the STORAGE_DIR directory.
+var blobStore blob.BlobStore = newBlobStore()
+
+func newBlobStore() blob.BlobStore {
+	store, err := blob.NewLocalStore(configs.GetEnvStorageDir())
+	if err != nil {
+		log.WriteErrorWithMsg(err, "Unable to create the storage directory")
+		os.Exit(1) // Terminate if the objects have nowhere to be stored
+	}
+	return store
+}
+
+// Custom metadata is uploaded and returned in headers with this prefix, e.g. X-Meta-Author.
+const objectMetadataHeader = "X-Meta-"
+
+// BucketInfo describes a bucket and lists the objects in it whose names start with the prefix. With a delimiter, objects are listed like files
+// in a directory: only those without the delimiter after the prefix are listed, and the rest are grouped into the prefixes they share. The
+// object count, total size and update time describe the whole bucket, whatever the prefix.
+//
+// @Id bucketInfo
+// @Router /storage/{bucket} [get]
+// @Description "Returns the bucket with given name and lists its objects"
+// @Produce json
+// @Param bucket path string true "Name of the bucket to get"
+// @Param prefix query string false "Only list objects whose names start with this prefix"
+// @Param delimiter query string false "Group objects whose names contain this after the prefix, e.g. /"
+// @Param offset query integer false "The starting position of the current page of objects (e.g. For starting at the 17th object, offset=16)."
+// @Success 200 {object} schema.BucketInfo "A bucket"
+func BucketInfo(c *gin.Context) {
+	bucket := c.Param("bucket")
+	prefix := c.Query("prefix")
+	delimiter := c.Query("delimiter")
+
+	offset, ok := offsetQuery(c)
+	if !ok {
+		return
+	}
+
+	info, err := blobStore.Usage(c.Request.Context(), bucket)
+	if err != nil {
+		respondWithStorageError(c, err)
+		return
+	}
+	info.Prefix = prefix
+	info.Objects = []schema.ObjectInfo{}
+	info.Prefixes = []string{}
+
+	objects, err := blobStore.List(c.Request.Context(), bucket, prefix)
+	if err != nil {
+		respondWithStorageError(c, err)
+		return
+	}
+
+	seenPrefixes := make(map[string]bool)
+	for _, object := range objects {
+		if delimiter != "" {
+			if i := strings.Index(object.Name[len(prefix):], delimiter); i >= 0 {
+				shared := object.Name[:len(prefix)+i+len(delimiter)]
+				if !seenPrefixes[shared] {
+					seenPrefixes[shared] = true
+					info.Prefixes = append(info.Prefixes, shared)
+				}
+				continue
+			}
+		}
+		info.Objects = append(info.Objects, object)
+	}
+
+	// Page through the listed objects
+	info.Objects = info.Objects[min(offset, len(info.Objects)):]
+	if limit := int(configs.GetEnvLimit()); len(info.Objects) > limit {
+		info.Objects = info.Objects[:limit]
+	}
+
+	c.JSON(http.StatusOK, responses.BucketResponse{Status: http.StatusOK, Message: "success", Data: info})
+}
+
+// ObjectInfo describes an object without returning its content.
+//
+// @Id objectInfo
+// @Router /storage/{bucket}/info/{objectID} [get]
+// @Description "Returns the description of the object with given name"
+// @Produce json
+// @Param bucket path string true "Name of the bucket the object is in"
+// @Param objectID path string true "Name of the object to describe"
+// @Success 200 {object} schema.ObjectInfo "An object"
+func ObjectInfo(c *gin.Context) {
+	info, err := blobStore.Stat(c.Request.Context(), c.Param("bucket"), objectName(c))
+	if err != nil {
+		respondWithStorageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, responses.ObjectResponse{Status: http.StatusOK, Message: "success", Data: info})
+}
+
+// PostObject uploads the request body as an object, replacing any object with the same name. Its content type is taken from the Content-Type
+// header, or detected from the content when the header is missing or generic, and any X-Meta- headers are stored as its metadata. Uploads
+// larger than STORAGE_MAX_OBJECT_SIZE are refused, and uploading needs an API key with the write scope.
+//
+// @Id postObject
+// @Router /storage/{bucket}/post/{objectID} [post]
+// @Description "Uploads an object to the bucket with given name"
+// @Accept */*
+// @Produce json
+// @Security apiKey
+// @Param bucket path string true "Name of the bucket to upload to"
+// @Param objectID path string true "Name of the object to upload"
+// @Success 201 {object} schema.ObjectInfo "The uploaded object"
+// @Failure 413 {object} responses.ErrorResponse "The object is too large"
+func PostObject(c *gin.Context) {
+	maxSize := configs.GetEnvStorageMaxObjectSize()
+	if c.Request.ContentLength > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, responses.ErrorResponse{Status: http.StatusRequestEntityTooLarge, Message: "error", Data: fmt.Sprintf("Objects can be at most %d bytes.", maxSize)})
+		return
+	}
+	body := bufio.NewReader(http.MaxBytesReader(c.Writer, c.Request.Body, maxSize))
+
+	// Detect the content type from the start of the content unless the client gave a specific one
+	contentType := c.GetHeader("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType == "application/octet-stream" {
+		head, _ := body.Peek(512)
+		contentType = http.DetectContentType(head)
+	}
+
+	metadata := make(map[string]string)
+	for header, values := range c.Request.Header {
+		if key, ok := strings.CutPrefix(header, objectMetadataHeader); ok && key != "" && len(values) > 0 {
+			metadata[strings.ToLower(key)] = values[0]
+		}
+	}
+
+	info, err := blobStore.Put(c.Request.Context(), c.Param("bucket"), objectName(c), body, contentType, metadata)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, responses.ErrorResponse{Status: http.StatusRequestEntityTooLarge, Message: "error", Data: fmt.Sprintf("Objects can be at most %d bytes.", maxSize)})
+		return
+	}
+	if err != nil {
+		respondWithStorageError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, responses.ObjectResponse{Status: http.StatusCreated, Message: "success", Data: info})
+}
+
+// GetObject returns the content of an object, with its metadata in X-Meta- headers. Range requests and conditional requests on the ETag or
+// modification time are supported.
+//
+// @Id getObject
+// @Router /storage/{bucket}/get/{objectID} [get]
+// @Description "Returns the content of the object with given name"
+// @Produce */*
+// @Param bucket path string true "Name of the bucket the object is in"
+// @Param objectID path string true "Name of the object to get"
+// @Param Range header string false "The byte range to return, e.g. bytes=0-1023"
+// @Success 200 {file} file "The object's content"
+// @Success 206 {file} file "The requested range of the object's content"
+func GetObject(c *gin.Context) {
+	object, info, err := blobStore.Open(c.Request.Context(), c.Param("bucket"), objectName(c))
+	if err != nil {
+		respondWithStorageError(c, err)
+		return
+	}
+	defer object.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", info.Content_type)
+	header.Set("ETag", strconv.Quote(info.Etag))
+	for key, value := range info.Metadata {
+		header.Set(objectMetadataHeader+key, value)
+	}
+	http.ServeContent(c.Writer, c.Request, info.Name, info.Updated_at, object)
+}
+
+// objectName returns the object name matched by the route's wildcard.
+func objectName(c *gin.Context) string {
+	return strings.TrimPrefix(c.Param("objectID"), "/")
+}
+
+// respondWithStorageError responds with the status matching an error from the blob store.
+func respondWithStorageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, blob.ErrNotFound):
+		c.JSON(http.StatusNotFound, responses.ErrorResponse{Status: http.StatusNotFound, Message: "error", Data: err.Error()})
+	case errors.Is(err, blob.ErrInvalidName):
+		c.JSON(http.StatusBadRequest, responses.ErrorResponse{Status: http.StatusBadRequest, Message: "error", Data: err.Error()})
+	default:
+		log.WriteError(err)
+		c.JSON(http.StatusInternalServerError, responses.ErrorResponse{Status: http.StatusInternalServerError, Message: "error", Data: err.Error()})
+	}
+}
@@ -0,0 +1,20 @@
+// Package responses provides standardized response structures for API endpoints related to the core curriculum.
+package responses
+
+import "github.com/UTDNebula/nebula-api/api/schema"
+
+// CoreAreasResponse represents the standardized HTTP response structure for API endpoints that return core curriculum areas. This response
+// includes a status code, a message, and a slice of core areas with their course and section counts.
+type CoreAreasResponse struct {
+	Status  int                      `json:"status"`
+	Message string                   `json:"message"`
+	Data    []schema.CoreAreaSummary `json:"data"`
+}
+
+// CoreCoursesResponse represents the standardized HTTP response structure for API endpoints that return the courses fulfilling a core area.
+// This response includes a status code, a message, and a slice of courses with their fulfilling sections.
+type CoreCoursesResponse struct {
+	Status  int                 `json:"status"`
+	Message string              `json:"message"`
+	Data    []schema.CoreCourse `json:"data"`
+}
@@ -31,3 +31,31 @@ type SingleCourseResponse struct {
 	Message string        `json:"message"`
 	Data    schema.Course `json:"data"`
 }
+
+// CourseOfferingsResponse represents the standardized HTTP response structure for API endpoints that return the offering history of a course. This
+// response includes a status code, a message, and the course's offering history and prediction.
+//
+// Fields:
+//
+//	Status:  The HTTP status code indicating the result of the request (e.g., 200 for success).
+//	Message: A brief description of the result of the request (e.g., "success" or "error").
+//	Data:    A CourseOfferings object containing the past terms, predicted next term and catalog mismatches.
+type CourseOfferingsResponse struct {
+	Status  int                    `json:"status"`
+	Message string                 `json:"message"`
+	Data    schema.CourseOfferings `json:"data"`
+}
+
+// CourseProfessorsResponse represents the standardized HTTP response structure for API endpoints that compare the professors of a course. This
+// response includes a status code, a message, and a slice of the course's professors with their grades and evaluations.
+//
+// Fields:
+//
+//	Status:  The HTTP status code indicating the result of the request (e.g., 200 for success).
+//	Message: A brief description of the result of the request (e.g., "success" or "error").
+//	Data:    A slice of CourseProfessor objects, one per professor who has taught the course.
+type CourseProfessorsResponse struct {
+	Status  int                      `json:"status"`
+	Message string                   `json:"message"`
+	Data    []schema.CourseProfessor `json:"data"`
+}
@@ -16,3 +16,30 @@ type EvaluationResponse struct {
 	Message string            `json:"message"`
 	Data    schema.Evaluation `json:"data"`
 }
+
+// ScrapeJobResponse represents the standardized HTTP response structure for API endpoints that return an evaluation scrape job.
+//
+// Fields:
+//
+//	Status:  The HTTP status code indicating the result of the request (e.g., 200 for success, 202 for a newly queued job).
+//	Message: A brief description of the result of the request (e.g., "success" or "error").
+//	Data:    The schema.ScrapeJob, including where to find the evaluation once it has succeeded.
+type ScrapeJobResponse struct {
+	Status  int              `json:"status"`
+	Message string           `json:"message"`
+	Data    schema.ScrapeJob `json:"data"`
+}
+
+// EvaluationAggregateResponse represents the standardized HTTP response structure for API endpoints that return the combined evaluations of a
+// course or professor.
+//
+// Fields:
+//
+//	Status:  The HTTP status code indicating the result of the request (e.g., 200 for success).
+//	Message: A brief description of the result of the request (e.g., "success" or "error").
+//	Data:    The schema.EvaluationAggregate combining the evaluations.
+type EvaluationAggregateResponse struct {
+	Status  int                        `json:"status"`
+	Message string                     `json:"message"`
+	Data    schema.EvaluationAggregate `json:"data"`
+}
@@ -0,0 +1,18 @@
+// Package responses provides standardized response structures for API endpoints related to campus events.
+package responses
+
+import "github.com/UTDNebula/nebula-api/api/schema"
+
+// MultiEventResponse represents the standardized HTTP response structure for API endpoints that return multiple events.
+type MultiEventResponse struct {
+	Status  int            `json:"status"`
+	Message string         `json:"message"`
+	Data    []schema.Event `json:"data"`
+}
+
+// SingleEventResponse represents the standardized HTTP response structure for API endpoints that return a single event.
+type SingleEventResponse struct {
+	Status  int          `json:"status"`
+	Message string       `json:"message"`
+	Data    schema.Event `json:"data"`
+}
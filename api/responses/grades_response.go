@@ -1,6 +1,8 @@
 // Package responses provides standardized response structures for API endpoints related to course data and evaluations.
 package responses
 
+import "github.com/UTDNebula/nebula-api/api/schema"
+
 // GradeResponse represents the standardized HTTP response structure for API endpoints that return grade data. This response includes
 // a status code, a message, and the grade data itself.
 //
@@ -14,3 +16,24 @@ type GradeResponse struct {
 	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
 }
+
+// GradeRankingsResponse represents the standardized HTTP response structure for API endpoints that return grade leaderboards. This response
+// includes a status code, a message, and the ranked entries.
+//
+// Fields:
+//
+//	Status:  The HTTP status code indicating the result of the request (e.g., 200 for success).
+//	Message: A brief description of the result of the request (e.g., "success" or "error").
+//	Data:    A slice of schema.GradeRanking, ordered by rank.
+type GradeRankingsResponse struct {
+	Status  int                   `json:"status"`
+	Message string                `json:"message"`
+	Data    []schema.GradeRanking `json:"data"`
+}
+
+// GradeQualityResponse represents the response structure for the sections whose grade distributions have data-quality issues.
+type GradeQualityResponse struct {
+	Status  int                        `json:"status"`
+	Message string                     `json:"message"`
+	Data    []schema.GradeQualityIssue `json:"data"`
+}
@@ -0,0 +1,18 @@
+// Package responses provides standardized response structures for API endpoints related to student organizations.
+package responses
+
+import "github.com/UTDNebula/nebula-api/api/schema"
+
+// MultiOrganizationResponse represents the standardized HTTP response structure for API endpoints that return multiple organizations.
+type MultiOrganizationResponse struct {
+	Status  int                   `json:"status"`
+	Message string                `json:"message"`
+	Data    []schema.Organization `json:"data"`
+}
+
+// SingleOrganizationResponse represents the standardized HTTP response structure for API endpoints that return a single organization.
+type SingleOrganizationResponse struct {
+	Status  int                 `json:"status"`
+	Message string              `json:"message"`
+	Data    schema.Organization `json:"data"`
+}
@@ -0,0 +1,18 @@
+// Package responses provides standardized response structures for API endpoints related to stored objects.
+package responses
+
+import "github.com/UTDNebula/nebula-api/api/schema"
+
+// BucketResponse represents the standardized HTTP response structure for API endpoints that describe a bucket and list its objects.
+type BucketResponse struct {
+	Status  int               `json:"status"`
+	Message string            `json:"message"`
+	Data    schema.BucketInfo `json:"data"`
+}
+
+// ObjectResponse represents the standardized HTTP response structure for API endpoints that describe a single stored object.
+type ObjectResponse struct {
+	Status  int               `json:"status"`
+	Message string            `json:"message"`
+	Data    schema.ObjectInfo `json:"data"`
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/UTDNebula/nebula-api/api/controllers"
+)
+
+// AdminRoute initializes the administrative routes and sets up the "/admin" group and defines the available endpoints. Every route in the group
+// requires an API key with the admin scope in the x-api-key header.
+// This function should be called during the application setup to register the administrative routes.
+//
+// The following routes are available:
+//
+//	OPTIONS /admin:                  Calls the Preflight controller to handle CORS preflight requests.
+//	GET /admin/data-quality/grades:  Calls the GradeDataQuality controller to list the sections with malformed or older grade distributions.
+//	POST /admin/grade-aggregates/refresh: Calls the RefreshGradeAggregates controller to rebuild the precomputed grade aggregates.
+//	POST /admin/autocomplete/refresh:     Calls the RefreshAutocomplete controller to rebuild the typeahead index and autocomplete DAG.
+func AdminRoute(router *gin.Engine) {
+	// All administrative routes come here
+	adminGroup := router.Group("/admin")
+
+	adminGroup.OPTIONS("", controllers.Preflight)
+
+	adminGroup.Use(controllers.RequireAdmin)
+	adminGroup.GET("data-quality/grades", controllers.GradeDataQuality)
+	adminGroup.POST("grade-aggregates/refresh", controllers.RefreshGradeAggregates)
+	adminGroup.POST("autocomplete/refresh", controllers.RefreshAutocomplete)
+}
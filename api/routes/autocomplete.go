@@ -12,10 +12,12 @@ import (
 //
 // The following routes are available:
 //
+//	GET /autocomplete:      Calls the Autocomplete controller to search courses and professors as the user types.
 //	GET /autocomplete/dag:  Calls the AutocompleteDAG controller to handle autocomplete requests for Directed Acyclic Graphs (DAG).
 func AutocompleteRoute(router *gin.Engine) {
 	// All routes related to autocomplete come here
 	autocompleteGroup := router.Group("/autocomplete")
 
+	autocompleteGroup.GET("", controllers.Autocomplete)
 	autocompleteGroup.GET("/dag", controllers.AutocompleteDAG)
 }
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/UTDNebula/nebula-api/api/controllers"
+)
+
+// CoreRoute initializes the routes related to the core curriculum and sets up the "/core" group and defines the available endpoints.
+// This function should be called during the application setup to register the core-related routes.
+//
+// The following routes are available:
+//
+//	OPTIONS /core:               Calls the Preflight controller to handle CORS preflight requests.
+//	GET /core:                   Calls the CoreAll controller to retrieve every core area with its course and section counts.
+//	GET /core/:flag/courses:     Calls the CoreCourses controller to retrieve the courses and sections fulfilling a core area.
+func CoreRoute(router *gin.Engine) {
+	// All routes related to the core curriculum come here
+	coreGroup := router.Group("/core")
+
+	coreGroup.OPTIONS("", controllers.Preflight)
+	coreGroup.GET("", controllers.CoreAll)
+	coreGroup.GET(":flag/courses", controllers.CoreCourses)
+}
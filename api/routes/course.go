@@ -15,6 +15,9 @@ import (
 //	GET /course:           Calls the CourseSearch controller to search for courses based on provided query parameters.
 //	GET /course/:id:       Calls the CourseById controller to retrieve a course by its ID.
 //	GET /course/all:       Calls the CourseAll controller to retrieve all available courses.
+//	GET /course/:id/offerings: Calls the CourseOfferingsById controller to retrieve the offering history and next predicted offering of a course.
+//	GET /course/:id/professors: Calls the CourseProfessors controller to compare the grades and evaluations of every professor of a course.
+//	GET /course/:id/evaluations: Calls the CourseEvaluations controller to combine the evaluations of every section of a course.
 func CourseRoute(router *gin.Engine) {
 	// All routes related to courses come here
 	courseGroup := router.Group("/course")
@@ -23,4 +26,7 @@ func CourseRoute(router *gin.Engine) {
 	courseGroup.GET("", controllers.CourseSearch)
 	courseGroup.GET(":id", controllers.CourseById)
 	courseGroup.GET("all", controllers.CourseAll)
+	courseGroup.GET(":id/offerings", controllers.CourseOfferingsById)
+	courseGroup.GET(":id/professors", controllers.CourseProfessors)
+	courseGroup.GET(":id/evaluations", controllers.CourseEvaluations)
 }
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/UTDNebula/nebula-api/api/controllers"
+)
+
+// EvaluationRoute initializes the routes related to evaluation scraping and sets up the "/evaluation" group and defines the available endpoints.
+// This function should be called during the application setup to register the evaluation-related routes.
+//
+// The following routes are available:
+//
+//	OPTIONS /evaluation:        Calls the Preflight controller to handle CORS preflight requests.
+//	GET /evaluation/jobs/:id:   Calls the EvaluationJobById controller to retrieve the status of an evaluation scrape job.
+func EvaluationRoute(router *gin.Engine) {
+	// All routes related to evaluations come here
+	evaluationGroup := router.Group("/evaluation")
+
+	evaluationGroup.OPTIONS("", controllers.Preflight)
+	evaluationGroup.GET("jobs/:id", controllers.EvaluationJobById)
+}
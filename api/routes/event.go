@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/UTDNebula/nebula-api/api/controllers"
+)
+
+// EventRoute initializes the routes related to campus events and sets up the "/event" group and defines the available endpoints. This function
+// should be called during the application setup to register the event-related routes.
+//
+// The following routes are available:
+//
+//	OPTIONS /event:         Calls the Preflight controller to handle CORS preflight requests.
+//	GET /event:             Calls the EventSearch controller to search events by time window and facets.
+//	GET /event/feed.ics:    Calls the EventFeed controller to retrieve the events matching the same filters as an iCalendar feed.
+//	GET /event/:id:         Calls the EventById controller to retrieve a specific event by its unique identifier.
+func EventRoute(router *gin.Engine) {
+	// All routes related to events come here
+	eventGroup := router.Group("/event")
+
+	eventGroup.OPTIONS("", controllers.Preflight)
+	eventGroup.GET("", controllers.EventSearch)
+	eventGroup.GET("feed.ics", controllers.EventFeed)
+	eventGroup.GET(":id", controllers.EventById)
+}
@@ -14,6 +14,9 @@ import (
 //	OPTIONS /grades:       Calls the Preflight controller to handle CORS preflight requests.
 //	GET /grades/semester:  Calls the GradeAggregationSemester controller  to retrieve aggregated grades by semester.
 //	GET /grades/overall:    Calls the GradesAggregationOverall controller to retrieve overall grade aggregations.
+//	GET /grades/trend:      Calls the GradeTrend controller to fit trends to the per-semester mean GPA and DFW rate.
+//	POST /grades/compare:   Calls the GradeComparison controller to test whether the grades matching two sets of filters differ.
+//	GET /grades/rankings:   Calls the GradeRankings controller to rank courses or professors by a grade statistic.
 func GradesRoute(router *gin.Engine) {
 	// All routes related to sections come here
 	gradesGroup := router.Group("/grades")
@@ -26,4 +29,7 @@ func GradesRoute(router *gin.Engine) {
 
 	gradesGroup.GET("semester", controllers.GradeAggregationSemester())
 	gradesGroup.GET("overall", controllers.GradesAggregationOverall())
+	gradesGroup.GET("trend", controllers.GradeTrend)
+	gradesGroup.POST("compare", controllers.GradeComparison)
+	gradesGroup.GET("rankings", controllers.GradeRankings)
 }
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/UTDNebula/nebula-api/api/controllers"
+)
+
+// OrganizationRoute initializes the routes related to student organizations and sets up the "/organization" group and defines the available
+// endpoints. This function should be called during the application setup to register the organization-related routes.
+//
+// The following routes are available:
+//
+//	OPTIONS /organization:         Calls the Preflight controller to handle CORS preflight requests.
+//	GET /organization:             Calls the OrganizationSearch controller to search organizations by text and categories.
+//	GET /organization/:id:         Calls the OrganizationById controller to retrieve a specific organization by its unique identifier.
+//	GET /organization/:id/image:   Calls the OrganizationImage controller to retrieve the picture of a specific organization.
+func OrganizationRoute(router *gin.Engine) {
+	// All routes related to organizations come here
+	organizationGroup := router.Group("/organization")
+
+	organizationGroup.OPTIONS("", controllers.Preflight)
+	organizationGroup.GET("", controllers.OrganizationSearch)
+	organizationGroup.GET(":id", controllers.OrganizationById)
+	organizationGroup.GET(":id/image", controllers.OrganizationImage)
+}
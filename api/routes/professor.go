@@ -15,6 +15,7 @@ import (
 //	GET /professor:            Calls the ProfessorSearch controller to retrieve a list of professors based on search criteria.
 //	GET /professor/:id:        Calls the ProfessorById controller to retrieve details of a specific professor by their unique identifier.
 //	GET /professor/all:        Calls the ProfessorAll controller to retrieve all professors in the database.
+//	GET /professor/:id/evaluations: Calls the ProfessorEvaluations controller to combine the evaluations of every section of a professor.
 func ProfessorRoute(router *gin.Engine) {
 	// All routes related to professors come here
 	professorGroup := router.Group("/professor")
@@ -23,4 +24,5 @@ func ProfessorRoute(router *gin.Engine) {
 	professorGroup.GET("", controllers.ProfessorSearch)
 	professorGroup.GET(":id", controllers.ProfessorById)
 	professorGroup.GET("all", controllers.ProfessorAll)
+	professorGroup.GET(":id/evaluations", controllers.ProfessorEvaluations)
 }
@@ -15,6 +15,7 @@ import (
 //	GET /section:                   Calls the SectionSearch controller to retrieve a list of sections based on search criteria.
 //	GET /section/:id:               Calls the SectionById controller to retrieve details of a specific section by its unique identifier.
 //	GET /section/:id/evaluation:    Calls the EvalBySectionID controller to retrieve evaluations related to a specific section.
+//	GET /section/:id/grades:        Calls the GradesBySectionID controller to retrieve the grade distribution and statistics of a specific section.
 func SectionRoute(router *gin.Engine) {
 	// All routes related to sections come here
 	sectionGroup := router.Group("/section")
@@ -23,4 +24,5 @@ func SectionRoute(router *gin.Engine) {
 	sectionGroup.GET("", controllers.SectionSearch)
 	sectionGroup.GET(":id", controllers.SectionById)
 	sectionGroup.GET(":id/evaluation", controllers.EvalBySectionID)
+	sectionGroup.GET(":id/grades", controllers.GradesBySectionID)
 }
@@ -7,24 +7,22 @@ import (
 )
 
 // StorageRoute initializes the routes related to storage functionality and sets up the "/storage" group and defines the available endpoints.
-// This function should be called during the application setup to register the storage-related routes.
+// This function should be called during the application setup to register the storage-related routes. Object names may contain slashes.
 //
-// The following routes are available (currently commented out):
+// The following routes are available:
 //
 //	OPTIONS /storage:                		Calls the Preflight controller to handle CORS preflight requests.
 //	GET /storage/:bucket:           		Calls the BucketInfo controller to retrieve information about a specific storage bucket.
-//	GET /storage/:bucket/info/:objectID: 	Calls the ObjectInfo controller to retrieve information about a specific object within the given bucket.
-//	POST /storage/:bucket/post/:objectID: 	Calls the PostObject controller to upload an object to the specified bucket.
-//	GET /storage/:bucket/get/:objectID: 	Calls the GetObject controller to retrieve a specific object from the bucket.
+//	GET /storage/:bucket/info/*objectID: 	Calls the ObjectInfo controller to retrieve information about a specific object within the given bucket.
+//	POST /storage/:bucket/post/*objectID: 	Calls the PostObject controller to upload an object to the specified bucket. Requires an API key with the write scope.
+//	GET /storage/:bucket/get/*objectID: 	Calls the GetObject controller to retrieve a specific object from the bucket.
 func StorageRoute(router *gin.Engine) {
 	// All routes related to storage come here
 	storageGroup := router.Group("/storage")
 
 	storageGroup.OPTIONS("", controllers.Preflight)
-	/*
-		storageGroup.GET(":bucket", controllers.BucketInfo())
-		storageGroup.GET(":bucket/info/:objectID", controllers.ObjectInfo())
-		storageGroup.POST(":bucket/post/:objectID", controllers.PostObject())
-		storageGroup.GET(":bucket/get/:objectID", controllers.GetObject())
-	*/
+	storageGroup.GET(":bucket", controllers.BucketInfo)
+	storageGroup.GET(":bucket/info/*objectID", controllers.ObjectInfo)
+	storageGroup.POST(":bucket/post/*objectID", controllers.RequireWrite, controllers.PostObject)
+	storageGroup.GET(":bucket/get/*objectID", controllers.GetObject)
 }
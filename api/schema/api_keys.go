@@ -0,0 +1,63 @@
+package schema
+
+import "time"
+
+// Scopes an API key can be granted. Each scope includes the ones before it: a write key can also read, and an admin key can do anything.
+const (
+	API_KEY_SCOPE_READ  = "read"
+	API_KEY_SCOPE_WRITE = "write"
+	API_KEY_SCOPE_ADMIN = "admin"
+)
+
+// APIKeyScopes lists the scopes from least to most privileged.
+var APIKeyScopes = []string{API_KEY_SCOPE_READ, API_KEY_SCOPE_WRITE, API_KEY_SCOPE_ADMIN}
+
+// APIKey is an issued API key. Only a hash of the key's secret is stored, so a lost key can't be recovered, only revoked and replaced.
+//
+// Fields:
+//
+//	Id:          The public part of the key, which it is looked up by.
+//	Name:        Who or what the key was issued to.
+//	Hash:        The SHA-256 hash of the key's secret, in hex.
+//	Scopes:      The scopes the key was granted.
+//	Created_at:  When the key was issued.
+//	Expires_at:  When the key stops working, or zero if it never expires.
+//	Revoked_at:  When the key was revoked, or zero if it hasn't been.
+type APIKey struct {
+	Id         string    `bson:"_id" json:"_id"`
+	Name       string    `bson:"name" json:"name"`
+	Hash       string    `bson:"hash" json:"hash"`
+	Scopes     []string  `bson:"scopes" json:"scopes"`
+	Created_at time.Time `bson:"created_at" json:"created_at"`
+	Expires_at time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	Revoked_at time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key was granted the scope, or a scope that includes it.
+func (key APIKey) HasScope(scope string) bool {
+	required := scopeRank(scope)
+	if required < 0 {
+		return false
+	}
+	for _, granted := range key.Scopes {
+		if scopeRank(granted) >= required {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidAPIKeyScope reports whether a scope is one of APIKeyScopes.
+func ValidAPIKeyScope(scope string) bool {
+	return scopeRank(scope) >= 0
+}
+
+// scopeRank returns the position of a scope in APIKeyScopes, or -1 for unknown scopes.
+func scopeRank(scope string) int {
+	for i, known := range APIKeyScopes {
+		if scope == known {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,61 @@
+package schema
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The types of result a typeahead search can return.
+const (
+	SUGGESTION_COURSE    = "course"
+	SUGGESTION_PROFESSOR = "professor"
+)
+
+// Suggestion is a single typeahead search result. Courses are identified by the id of their latest catalog year and professors by their own id.
+// Results are ordered by score, which is only meaningful relative to the other results of the same search.
+type Suggestion struct {
+	Type           string             `bson:"type" json:"type"`
+	Id             primitive.ObjectID `bson:"_id" json:"_id"`
+	Label          string             `bson:"label" json:"label"`
+	Subject_prefix string             `bson:"subject_prefix,omitempty" json:"subject_prefix,omitempty"`
+	Course_number  string             `bson:"course_number,omitempty" json:"course_number,omitempty"`
+	Title          string             `bson:"title,omitempty" json:"title,omitempty"`
+	First_name     string             `bson:"first_name,omitempty" json:"first_name,omitempty"`
+	Last_name      string             `bson:"last_name,omitempty" json:"last_name,omitempty"`
+	Score          float64            `bson:"score" json:"score"`
+}
+
+// DAGPrefix is a subject prefix in the autocomplete DAG, which nests every course number, academic session, section and professor on record.
+type DAGPrefix struct {
+	Subject_prefix string      `bson:"subject_prefix" json:"subject_prefix"`
+	Course_numbers []DAGCourse `bson:"course_numbers" json:"course_numbers"`
+}
+
+// DAGCourse is a course number in the autocomplete DAG with the academic sessions it was taught in.
+type DAGCourse struct {
+	Course_number     string       `bson:"course_number" json:"course_number"`
+	Academic_sessions []DAGSession `bson:"academic_sessions" json:"academic_sessions"`
+}
+
+// DAGSession is an academic session of a course in the autocomplete DAG with its sections. Courses without sections have one session
+// without a name.
+type DAGSession struct {
+	Academic_session *DAGSessionName `bson:"academic_session" json:"academic_session"`
+	Sections         []DAGSection    `bson:"sections" json:"sections"`
+}
+
+// DAGSessionName holds the name of an academic session in the autocomplete DAG.
+type DAGSessionName struct {
+	Name string `bson:"name" json:"name"`
+}
+
+// DAGSection is a section in the autocomplete DAG with its professors.
+type DAGSection struct {
+	Section_number string         `bson:"section_number" json:"section_number"`
+	Professors     []DAGProfessor `bson:"professors" json:"professors"`
+}
+
+// DAGProfessor is the name of a professor in the autocomplete DAG.
+type DAGProfessor struct {
+	First_name string `bson:"first_name,omitempty" json:"first_name,omitempty"`
+	Last_name  string `bson:"last_name,omitempty" json:"last_name,omitempty"`
+}
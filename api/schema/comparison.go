@@ -0,0 +1,286 @@
+package schema
+
+import (
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EvaluationOverview condenses a set of section evaluations into one mean score per experience category. Each score is the mean of every
+// question in the category, weighted by the number of responses to the question.
+type EvaluationOverview struct {
+	Evaluation_count      int     `bson:"evaluation_count" json:"evaluation_count"`
+	Course_experience     float64 `bson:"course_experience" json:"course_experience"`
+	Instructor_experience float64 `bson:"instructor_experience" json:"instructor_experience"`
+	Student_experience    float64 `bson:"student_experience" json:"student_experience"`
+}
+
+// NewEvaluationOverview condenses the given evaluations, returning nil when there are none.
+func NewEvaluationOverview(evaluations []Evaluation) *EvaluationOverview {
+	if len(evaluations) == 0 {
+		return nil
+	}
+
+	var course, instructor, student []EvaluationField
+	for _, evaluation := range evaluations {
+		course = append(course, evaluation.CourseExperience...)
+		instructor = append(instructor, evaluation.InstructorExperience...)
+		student = append(student, evaluation.StudentExperience...)
+	}
+
+	return &EvaluationOverview{
+		Evaluation_count:      len(evaluations),
+		Course_experience:     weightedFieldMean(course),
+		Instructor_experience: weightedFieldMean(instructor),
+		Student_experience:    weightedFieldMean(student),
+	}
+}
+
+// weightedFieldMean averages the mean score of each field, weighted by the field's responses.
+func weightedFieldMean(fields []EvaluationField) float64 {
+	total, responses := 0.0, 0
+	for _, field := range fields {
+		total += float64(field.Summary.Mean) * float64(field.Summary.Responses)
+		responses += field.Summary.Responses
+	}
+	if responses == 0 {
+		return 0
+	}
+	return math.Round(total/float64(responses)*100) / 100
+}
+
+// CourseProfessor summarizes how one professor has taught one course.
+//
+// Fields:
+//
+//	Professor_reference: The id of the professor.
+//	First_name:          The professor's first name.
+//	Last_name:           The professor's last name.
+//	Sections:            The ids of every section of the course the professor taught.
+//	Terms:               Every academic session the professor taught the course in, oldest first.
+//	Grades:              The combined grade distribution and statistics of those sections.
+//	Evaluation:          An overview of the sections' evaluations, or null when none are on record.
+type CourseProfessor struct {
+	Professor_reference primitive.ObjectID   `bson:"_id" json:"professor_reference"`
+	First_name          string               `bson:"first_name" json:"first_name"`
+	Last_name           string               `bson:"last_name" json:"last_name"`
+	Sections            []primitive.ObjectID `bson:"sections" json:"sections"`
+	Terms               []string             `bson:"terms" json:"terms"`
+	Grades              GradeSummary         `bson:"-" json:"grades"`
+	Evaluation          *EvaluationOverview  `bson:"-" json:"evaluation"`
+}
+
+// The confidence level of the interval around a difference in mean GPA, and the significance level of the comparison tests.
+const COMPARISON_CONFIDENCE_LEVEL = 0.95
+
+// MeanDifference is the difference between the mean GPAs of two grade distributions (b minus a), with a confidence interval from Welch's t-test.
+type MeanDifference struct {
+	Difference         float64 `bson:"difference" json:"difference"`
+	Standard_error     float64 `bson:"standard_error" json:"standard_error"`
+	Degrees_of_freedom float64 `bson:"degrees_of_freedom" json:"degrees_of_freedom"`
+	Confidence_level   float64 `bson:"confidence_level" json:"confidence_level"`
+	Lower_bound        float64 `bson:"lower_bound" json:"lower_bound"`
+	Upper_bound        float64 `bson:"upper_bound" json:"upper_bound"`
+	P_value            float64 `bson:"p_value" json:"p_value"`
+	Significant        bool    `bson:"significant" json:"significant"`
+}
+
+// ChiSquareTest is a chi-square test of whether two grade distributions, withdrawals included, are spread across the grades differently.
+// Cramers_v measures the size of the difference from 0 (none) to 1.
+type ChiSquareTest struct {
+	Statistic          float64 `bson:"statistic" json:"statistic"`
+	Degrees_of_freedom int     `bson:"degrees_of_freedom" json:"degrees_of_freedom"`
+	P_value            float64 `bson:"p_value" json:"p_value"`
+	Cramers_v          float64 `bson:"cramers_v" json:"cramers_v"`
+	Significant        bool    `bson:"significant" json:"significant"`
+}
+
+// MannWhitneyTest is a Mann-Whitney U test of whether the grades of one distribution tend to be higher than the other's, using the normal
+// approximation with a correction for ties. Probability_a_higher is the chance that a random grade from a is higher than one from b, counting
+// ties as half.
+type MannWhitneyTest struct {
+	U                    float64 `bson:"u" json:"u"`
+	Z                    float64 `bson:"z" json:"z"`
+	P_value              float64 `bson:"p_value" json:"p_value"`
+	Probability_a_higher float64 `bson:"probability_a_higher" json:"probability_a_higher"`
+	Significant          bool    `bson:"significant" json:"significant"`
+}
+
+// GradeComparison compares two grade distributions. Each test is nil when either distribution has too few students for it.
+type GradeComparison struct {
+	A                   GradeSummary     `bson:"a" json:"a"`
+	B                   GradeSummary     `bson:"b" json:"b"`
+	Mean_gpa_difference *MeanDifference  `bson:"mean_gpa_difference" json:"mean_gpa_difference"`
+	Chi_square          *ChiSquareTest   `bson:"chi_square" json:"chi_square"`
+	Mann_whitney        *MannWhitneyTest `bson:"mann_whitney" json:"mann_whitney"`
+}
+
+// NewGradeComparison compares the grade summaries a and b.
+func NewGradeComparison(a GradeSummary, b GradeSummary) GradeComparison {
+	countsA, countsB := a.Grade_distribution.Counts(), b.Grade_distribution.Counts()
+	return GradeComparison{
+		A:                   a,
+		B:                   b,
+		Mean_gpa_difference: compareMeanGPA(countsA, countsB),
+		Chi_square:          chiSquareTest(countsA, countsB),
+		Mann_whitney:        mannWhitneyTest(countsA, countsB),
+	}
+}
+
+// gradePointMoments returns the number of graded students along with the mean and sample variance of their grade points.
+func gradePointMoments(counts []int) (int, float64, float64) {
+	n, sum := 0, 0.0
+	for i, points := range GradePoints {
+		n += counts[i]
+		sum += points * float64(counts[i])
+	}
+	if n < 2 {
+		return n, 0, 0
+	}
+
+	mean, squares := sum/float64(n), 0.0
+	for i, points := range GradePoints {
+		squares += float64(counts[i]) * (points - mean) * (points - mean)
+	}
+	// Students who all have the same grade leave only rounding error, which mustn't pass for spread
+	variance := squares / float64(n-1)
+	if variance < 1e-12 {
+		variance = 0
+	}
+	return n, mean, variance
+}
+
+// compareMeanGPA runs Welch's t-test on the grade points of two distributions. It returns nil when either has fewer than two graded students or
+// neither has any spread.
+func compareMeanGPA(countsA []int, countsB []int) *MeanDifference {
+	nA, meanA, varianceA := gradePointMoments(countsA)
+	nB, meanB, varianceB := gradePointMoments(countsB)
+	if nA < 2 || nB < 2 {
+		return nil
+	}
+
+	termA, termB := varianceA/float64(nA), varianceB/float64(nB)
+	standardError := math.Sqrt(termA + termB)
+	if standardError == 0 {
+		return nil
+	}
+
+	// Welch-Satterthwaite approximation of the degrees of freedom
+	df := (termA + termB) * (termA + termB) / (termA*termA/float64(nA-1) + termB*termB/float64(nB-1))
+	difference := meanB - meanA
+	margin := studentTCritical(1-COMPARISON_CONFIDENCE_LEVEL, df) * standardError
+	pValue := studentTPValue(difference/standardError, df)
+
+	return &MeanDifference{
+		Difference:         roundPrecise(difference),
+		Standard_error:     roundPrecise(standardError),
+		Degrees_of_freedom: roundStat(df),
+		Confidence_level:   COMPARISON_CONFIDENCE_LEVEL,
+		Lower_bound:        roundPrecise(difference - margin),
+		Upper_bound:        roundPrecise(difference + margin),
+		P_value:            roundPrecise(pValue),
+		Significant:        pValue < 1-COMPARISON_CONFIDENCE_LEVEL,
+	}
+}
+
+// chiSquareTest runs a chi-square test of independence on the 2 by k table of two distributions' grade counts, leaving out grades neither has.
+// It returns nil when either distribution is empty or there is only one grade to compare.
+func chiSquareTest(countsA []int, countsB []int) *ChiSquareTest {
+	totalA, totalB := 0, 0
+	var columns [][2]int
+	for i := range countsA {
+		if countsA[i]+countsB[i] > 0 {
+			columns = append(columns, [2]int{countsA[i], countsB[i]})
+			totalA += countsA[i]
+			totalB += countsB[i]
+		}
+	}
+	if totalA == 0 || totalB == 0 || len(columns) < 2 {
+		return nil
+	}
+
+	total := float64(totalA + totalB)
+	statistic := 0.0
+	for _, column := range columns {
+		columnTotal := float64(column[0] + column[1])
+		for row, rowTotal := range []int{totalA, totalB} {
+			expected := columnTotal * float64(rowTotal) / total
+			statistic += (float64(column[row]) - expected) * (float64(column[row]) - expected) / expected
+		}
+	}
+
+	df := len(columns) - 1
+	pValue := chiSquarePValue(statistic, float64(df))
+	return &ChiSquareTest{
+		Statistic:          roundPrecise(statistic),
+		Degrees_of_freedom: df,
+		P_value:            roundPrecise(pValue),
+		Cramers_v:          roundPrecise(math.Sqrt(statistic / total)),
+		Significant:        pValue < 1-COMPARISON_CONFIDENCE_LEVEL,
+	}
+}
+
+// mannWhitneyTest runs a Mann-Whitney U test on the graded students of two distributions. Grades worth the same grade points (A+ and A) are
+// tied. It returns nil when either has no graded students or every student has the same grade.
+func mannWhitneyTest(countsA []int, countsB []int) *MannWhitneyTest {
+	// Merge the buckets that share a grade point value, keeping them in descending order
+	var levelsA, levelsB []float64
+	for i, points := range GradePoints {
+		if i > 0 && points == GradePoints[i-1] {
+			levelsA[len(levelsA)-1] += float64(countsA[i])
+			levelsB[len(levelsB)-1] += float64(countsB[i])
+			continue
+		}
+		levelsA = append(levelsA, float64(countsA[i]))
+		levelsB = append(levelsB, float64(countsB[i]))
+	}
+
+	var nA, nB, u, ties float64
+	for _, count := range levelsA {
+		nA += count
+	}
+	for _, count := range levelsB {
+		nB += count
+	}
+	if nA == 0 || nB == 0 {
+		return nil
+	}
+
+	// U counts the pairs where a's grade is higher, with ties counting half. Walking from the lowest grade up, bLower is the number of b's
+	// students with a lower grade than the current one.
+	bLower := 0.0
+	for i := len(levelsA) - 1; i >= 0; i-- {
+		u += levelsA[i] * (bLower + levelsB[i]/2)
+		bLower += levelsB[i]
+		tied := levelsA[i] + levelsB[i]
+		ties += tied*tied*tied - tied
+	}
+
+	n := nA + nB
+	mean := nA * nB / 2
+	variance := nA * nB / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance <= 0 {
+		return nil
+	}
+
+	z := (u - mean) / math.Sqrt(variance)
+	pValue := normalPValue(z)
+	return &MannWhitneyTest{
+		U:                    u,
+		Z:                    roundPrecise(z),
+		P_value:              roundPrecise(pValue),
+		Probability_a_higher: roundPrecise(u / (nA * nB)),
+		Significant:          pValue < 1-COMPARISON_CONFIDENCE_LEVEL,
+	}
+}
+
+// roundPrecise rounds a test result to four decimal places, since p-values and small differences lose too much at two.
+func roundPrecise(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+// GradeComparisonRequest holds the two sets of grade filters to compare.
+type GradeComparisonRequest struct {
+	A GradeFilters `json:"a"`
+	B GradeFilters `json:"b"`
+}
@@ -0,0 +1,153 @@
+package schema
+
+import "testing"
+
+// Grade counts (A+ through F, then W) of two groups to compare. The expected results were computed independently from the students' grade
+// points.
+var (
+	comparedA = []int{5, 10, 8, 6, 5, 4, 3, 2, 1, 1, 1, 0, 2, 3}
+	comparedB = []int{2, 4, 6, 8, 8, 6, 5, 4, 3, 2, 2, 1, 4, 1}
+)
+
+// singleGrade returns counts with every student in one bucket.
+func singleGrade(bucket int, students int) []int {
+	counts := make([]int, len(GradeLabels))
+	counts[bucket] = students
+	return counts
+}
+
+func TestCompareMeanGPA(t *testing.T) {
+	result := compareMeanGPA(comparedA, comparedB)
+	if result == nil {
+		t.Fatal("got no result")
+	}
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"difference", result.Difference, -0.5312},
+		{"standard error", result.Standard_error, 0.2108},
+		{"degrees of freedom", result.Degrees_of_freedom, 100.81},
+		{"lower bound", result.Lower_bound, -0.9494},
+		{"upper bound", result.Upper_bound, -0.1130},
+		{"p-value", result.P_value, 0.0133},
+	}
+	for _, check := range checks {
+		if !closeTo(check.got, check.want, 1e-4) {
+			t.Errorf("%s: got %v, want %v", check.name, check.got, check.want)
+		}
+	}
+	if !result.Significant {
+		t.Errorf("got an insignificant difference, want a significant one")
+	}
+
+	// Swapping the groups flips the difference but not the p-value
+	swapped := compareMeanGPA(comparedB, comparedA)
+	if swapped.Difference != -result.Difference || swapped.P_value != result.P_value {
+		t.Errorf("got %+v with the groups swapped, want the opposite difference and the same p-value as %+v", swapped, result)
+	}
+}
+
+func TestCompareMeanGPAWithoutEnoughData(t *testing.T) {
+	tests := map[string][2][]int{
+		"empty group":             {comparedA, make([]int, len(GradeLabels))},
+		"single student":          {comparedA, singleGrade(4, 1)},
+		"withdrawals only":        {comparedA, singleGrade(13, 10)},
+		"zero variance in both":   {singleGrade(2, 10), singleGrade(2, 20)},
+		"zero variance, A+ and A": {singleGrade(0, 5), singleGrade(1, 7)},
+	}
+	for name, counts := range tests {
+		if result := compareMeanGPA(counts[0], counts[1]); result != nil {
+			t.Errorf("%s: got %+v, want no result", name, result)
+		}
+	}
+
+	// One group without any spread still leaves the other's
+	if result := compareMeanGPA(singleGrade(4, 10), comparedB); result == nil {
+		t.Errorf("zero variance in one group: got no result, want one")
+	}
+}
+
+func TestChiSquareTest(t *testing.T) {
+	result := chiSquareTest(comparedA, comparedB)
+	if result == nil {
+		t.Fatal("got no result")
+	}
+	if result.Degrees_of_freedom != 13 {
+		t.Errorf("got %d degrees of freedom, want 13", result.Degrees_of_freedom)
+	}
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"statistic", result.Statistic, 10.8108},
+		{"p-value", result.P_value, 0.6267},
+		{"Cramer's V", result.Cramers_v, 0.3179},
+	}
+	for _, check := range checks {
+		if !closeTo(check.got, check.want, 1e-4) {
+			t.Errorf("%s: got %v, want %v", check.name, check.got, check.want)
+		}
+	}
+	if result.Significant {
+		t.Errorf("got a significant difference, want an insignificant one")
+	}
+
+	// Grades neither group has are left out of the degrees of freedom
+	sparse := chiSquareTest(singleGrade(0, 10), singleGrade(12, 10))
+	if sparse == nil || sparse.Degrees_of_freedom != 1 || !closeTo(sparse.Statistic, 20, 1e-9) || !closeTo(sparse.Cramers_v, 1, 1e-9) {
+		t.Errorf("completely different groups: got %+v, want a statistic of 20 with 1 degree of freedom and a Cramer's V of 1", sparse)
+	}
+
+	if result := chiSquareTest(comparedA, make([]int, len(GradeLabels))); result != nil {
+		t.Errorf("empty group: got %+v, want no result", result)
+	}
+	if result := chiSquareTest(singleGrade(3, 4), singleGrade(3, 9)); result != nil {
+		t.Errorf("single grade: got %+v, want no result", result)
+	}
+}
+
+func TestMannWhitneyTest(t *testing.T) {
+	result := mannWhitneyTest(comparedA, comparedB)
+	if result == nil {
+		t.Fatal("got no result")
+	}
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"U", result.U, 1745},
+		{"z", result.Z, 2.8343},
+		{"p-value", result.P_value, 0.0046},
+		{"probability a is higher", result.Probability_a_higher, 0.6610},
+	}
+	for _, check := range checks {
+		if !closeTo(check.got, check.want, 1e-4) {
+			t.Errorf("%s: got %v, want %v", check.name, check.got, check.want)
+		}
+	}
+	if !result.Significant {
+		t.Errorf("got an insignificant difference, want a significant one")
+	}
+
+	// A+ and A are worth the same grade points, so they tie
+	tied := mannWhitneyTest(
+		[]int{3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 0},
+		[]int{0, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 0},
+	)
+	if tied == nil || tied.Probability_a_higher != 0.5 || tied.Z != 0 {
+		t.Errorf("A+ and F against A and F: got %+v, want them tied", tied)
+	}
+
+	tests := map[string][2][]int{
+		"empty group":      {comparedA, make([]int, len(GradeLabels))},
+		"withdrawals only": {comparedA, singleGrade(13, 10)},
+		"single grade":     {singleGrade(5, 4), singleGrade(5, 6)},
+		"A+ and A only":    {singleGrade(0, 4), singleGrade(1, 6)},
+	}
+	for name, counts := range tests {
+		if result := mannWhitneyTest(counts[0], counts[1]); result != nil {
+			t.Errorf("%s: got %+v, want no result", name, result)
+		}
+	}
+}
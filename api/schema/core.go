@@ -0,0 +1,60 @@
+package schema
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// CoreArea represents one component area of the Texas core curriculum, identified by the numeric code used in Section.Core_flags and
+// CoreRequirement.CoreFlag.
+type CoreArea struct {
+	Flag           string `bson:"flag" json:"flag"`
+	Name           string `bson:"name" json:"name"`
+	Required_hours int    `bson:"required_hours" json:"required_hours"`
+}
+
+// CoreAreas is the registry of core curriculum component areas, ordered by core code.
+var CoreAreas = []CoreArea{
+	{Flag: "010", Name: "Communication", Required_hours: 6},
+	{Flag: "020", Name: "Mathematics", Required_hours: 3},
+	{Flag: "030", Name: "Life and Physical Sciences", Required_hours: 6},
+	{Flag: "040", Name: "Language, Philosophy and Culture", Required_hours: 3},
+	{Flag: "050", Name: "Creative Arts", Required_hours: 3},
+	{Flag: "060", Name: "American History", Required_hours: 6},
+	{Flag: "070", Name: "Government/Political Science", Required_hours: 6},
+	{Flag: "080", Name: "Social and Behavioral Sciences", Required_hours: 3},
+	{Flag: "090", Name: "Component Area Option", Required_hours: 6},
+}
+
+// LookupCoreArea returns the core area registered under the given core code.
+func LookupCoreArea(flag string) (CoreArea, bool) {
+	for _, area := range CoreAreas {
+		if area.Flag == flag {
+			return area, true
+		}
+	}
+	return CoreArea{}, false
+}
+
+// CoreAreaSummary is a core area together with how many courses and sections fulfill it.
+type CoreAreaSummary struct {
+	CoreArea      `bson:",inline" json:",inline"`
+	Course_count  int `bson:"course_count" json:"course_count"`
+	Section_count int `bson:"section_count" json:"section_count"`
+}
+
+// CoreSection is a short reference to a section that fulfills a core area.
+type CoreSection struct {
+	Id               primitive.ObjectID `bson:"_id" json:"_id"`
+	Section_number   string             `bson:"section_number" json:"section_number"`
+	Academic_session string             `bson:"academic_session" json:"academic_session"`
+}
+
+// CoreCourse is a course with sections that fulfill a core area. Grades is the combined grade distribution and statistics of those sections and
+// is only included when requested.
+type CoreCourse struct {
+	Course_reference primitive.ObjectID `bson:"_id" json:"course_reference"`
+	Subject_prefix   string             `bson:"subject_prefix" json:"subject_prefix"`
+	Course_number    string             `bson:"course_number" json:"course_number"`
+	Title            string             `bson:"title" json:"title"`
+	Section_count    int                `bson:"section_count" json:"section_count"`
+	Sections         []CoreSection      `bson:"sections" json:"sections"`
+	Grades           *GradeSummary      `bson:"-" json:"grades,omitempty"`
+}
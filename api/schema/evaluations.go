@@ -0,0 +1,232 @@
+package schema
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LikertResponses are the possible responses to an evaluation question, from most negative to most positive.
+var LikertResponses = []EvaluationResponse{STRONGLY_DISAGREE, DISAGREE, NEUTRAL, AGREE, STRONGLY_AGREE}
+
+// Score returns the response's position on the 1 to 5 Likert scale, or 0 if it isn't a valid response.
+func (r EvaluationResponse) Score() int {
+	for i, response := range LikertResponses {
+		if response == r {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// NewEvaluationSummary computes the median, mean and sample standard deviation of a question's Likert scores from the number of each response.
+func NewEvaluationSummary(counts map[EvaluationResponse]int) EvaluationSummary {
+	var scores []int
+	total := 0
+	for response, count := range counts {
+		for i := 0; i < count; i++ {
+			scores = append(scores, response.Score())
+		}
+		total += count
+	}
+	summary := EvaluationSummary{Responses: total}
+	if total == 0 {
+		return summary
+	}
+	sort.Ints(scores)
+
+	sum := 0.0
+	for _, score := range scores {
+		sum += float64(score)
+	}
+	mean := sum / float64(total)
+
+	median := float64(scores[total/2])
+	if total%2 == 0 {
+		median = float64(scores[total/2-1]+scores[total/2]) / 2
+	}
+
+	squares := 0.0
+	for _, score := range scores {
+		squares += (float64(score) - mean) * (float64(score) - mean)
+	}
+	standardDeviation := 0.0
+	if total > 1 {
+		standardDeviation = math.Sqrt(squares / float64(total-1))
+	}
+
+	summary.Mean = float32(roundStat(mean))
+	summary.Median = float32(median)
+	summary.StandardDeviation = float32(roundStat(standardDeviation))
+	return summary
+}
+
+// Statuses of a scrape job
+const (
+	SCRAPE_JOB_QUEUED    = "queued"
+	SCRAPE_JOB_RUNNING   = "running"
+	SCRAPE_JOB_SUCCEEDED = "succeeded"
+	SCRAPE_JOB_FAILED    = "failed"
+)
+
+// ScrapeJob is a request to scrape the evaluation of a section in the background. Failed attempts are retried with backoff until the job runs out
+// of attempts.
+//
+// Fields:
+//
+//	Section_reference: The section whose evaluation is scraped, which is also the id the evaluation is stored under.
+//	Status:            queued, running, succeeded or failed.
+//	Attempts:          How many times the job has been run.
+//	Last_error:        Why the last attempt failed, if it did.
+//	Run_after:         When the job may next be run.
+//	Lease_until:       When a running job is given up on and may be claimed by another worker.
+//	Job_uri:           Where the job's status can be retrieved.
+//	Evaluation_uri:    Where the evaluation can be retrieved once the job has succeeded.
+type ScrapeJob struct {
+	Id                primitive.ObjectID `bson:"_id" json:"_id"`
+	Section_reference primitive.ObjectID `bson:"section_reference" json:"section_reference"`
+	Status            string             `bson:"status" json:"status"`
+	Attempts          int                `bson:"attempts" json:"attempts"`
+	Last_error        string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	Created_at        time.Time          `bson:"created_at" json:"created_at"`
+	Updated_at        time.Time          `bson:"updated_at" json:"updated_at"`
+	Run_after         time.Time          `bson:"run_after" json:"run_after"`
+	Lease_until       time.Time          `bson:"lease_until,omitempty" json:"-"`
+	Finished_at       time.Time          `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	Job_uri           string             `bson:"-" json:"job_uri"`
+	Evaluation_uri    string             `bson:"-" json:"evaluation_uri,omitempty"`
+}
+
+// The experience categories an evaluation's questions are grouped into
+const (
+	EVALUATION_COURSE_EXPERIENCE     = "course_experience"
+	EVALUATION_INSTRUCTOR_EXPERIENCE = "instructor_experience"
+	EVALUATION_STUDENT_EXPERIENCE    = "student_experience"
+)
+
+// EvaluationQuestion combines the responses to one question across many evaluations. Questions are told apart by their category and
+// description, and their summary is computed from the combined counts, so every section counts in proportion to its responses.
+type EvaluationQuestion struct {
+	Category    string                     `bson:"category" json:"category"`
+	Description string                     `bson:"description" json:"description"`
+	Counts      map[EvaluationResponse]int `bson:"counts" json:"counts"`
+	Summary     EvaluationSummary          `bson:"summary" json:"summary"`
+}
+
+// EvaluationTerm combines the evaluations of one academic session.
+type EvaluationTerm struct {
+	Academic_session string               `bson:"academic_session" json:"academic_session"`
+	Evaluation_count int                  `bson:"evaluation_count" json:"evaluation_count"`
+	Mean             float64              `bson:"mean" json:"mean"`
+	Questions        []EvaluationQuestion `bson:"questions" json:"questions"`
+}
+
+// DepartmentRank places a course or professor's mean score among every course or professor of a department.
+//
+// Fields:
+//
+//	Subject_prefix: The department, identified by its subject prefix. A professor's department is the prefix of most of their evaluated sections.
+//	Compared:       How many courses or professors of the department have evaluations to compare against, including this one.
+//	Percentile:     The percentage of them with a lower mean score, counting ties as half.
+type DepartmentRank struct {
+	Subject_prefix string  `bson:"subject_prefix" json:"subject_prefix"`
+	Compared       int     `bson:"compared" json:"compared"`
+	Percentile     float64 `bson:"percentile" json:"percentile"`
+}
+
+// EvaluationAggregate combines the evaluations of every section of a course or professor.
+//
+// Fields:
+//
+//	Evaluation_count: How many section evaluations were combined.
+//	Mean:             The mean score of every response to every question.
+//	Questions:        Each question, combined across every evaluation.
+//	Terms:            The same, broken down by academic session, oldest first.
+//	Department:       How the mean score ranks within the department, or null when there's nothing to rank.
+type EvaluationAggregate struct {
+	Evaluation_count int                  `bson:"evaluation_count" json:"evaluation_count"`
+	Mean             float64              `bson:"mean" json:"mean"`
+	Questions        []EvaluationQuestion `bson:"questions" json:"questions"`
+	Terms            []EvaluationTerm     `bson:"terms" json:"terms"`
+	Department       *DepartmentRank      `bson:"department" json:"department"`
+}
+
+// NewEvaluationAggregate combines evaluations, using sessions to look up the academic session of each evaluation's section.
+func NewEvaluationAggregate(evaluations []Evaluation, sessions map[primitive.ObjectID]string) EvaluationAggregate {
+	aggregate := EvaluationAggregate{
+		Evaluation_count: len(evaluations),
+		Terms:            []EvaluationTerm{},
+	}
+	aggregate.Questions, aggregate.Mean = combineEvaluations(evaluations)
+
+	byTerm := make(map[string][]Evaluation)
+	var terms []string
+	for _, evaluation := range evaluations {
+		term := sessions[evaluation.Id]
+		if _, ok := byTerm[term]; !ok {
+			terms = append(terms, term)
+		}
+		byTerm[term] = append(byTerm[term], evaluation)
+	}
+	SortTermNames(terms)
+
+	for _, term := range terms {
+		questions, mean := combineEvaluations(byTerm[term])
+		aggregate.Terms = append(aggregate.Terms, EvaluationTerm{
+			Academic_session: term,
+			Evaluation_count: len(byTerm[term]),
+			Mean:             mean,
+			Questions:        questions,
+		})
+	}
+	return aggregate
+}
+
+// combineEvaluations sums the counts of each question across evaluations, in the order the questions first appear, and returns them along
+// with the mean score of every response.
+func combineEvaluations(evaluations []Evaluation) ([]EvaluationQuestion, float64) {
+	questions := []EvaluationQuestion{}
+	indexes := make(map[[2]string]int)
+	sum, responses := 0, 0
+
+	for _, evaluation := range evaluations {
+		categories := []struct {
+			name   string
+			fields []EvaluationField
+		}{
+			{EVALUATION_COURSE_EXPERIENCE, evaluation.CourseExperience},
+			{EVALUATION_INSTRUCTOR_EXPERIENCE, evaluation.InstructorExperience},
+			{EVALUATION_STUDENT_EXPERIENCE, evaluation.StudentExperience},
+		}
+		for _, category := range categories {
+			for _, field := range category.fields {
+				key := [2]string{category.name, field.Description}
+				index, ok := indexes[key]
+				if !ok {
+					index = len(questions)
+					indexes[key] = index
+					questions = append(questions, EvaluationQuestion{
+						Category:    category.name,
+						Description: field.Description,
+						Counts:      make(map[EvaluationResponse]int),
+					})
+				}
+				for response, count := range field.Counts {
+					questions[index].Counts[response] += count
+					sum += response.Score() * count
+					responses += count
+				}
+			}
+		}
+	}
+
+	for i := range questions {
+		questions[i].Summary = NewEvaluationSummary(questions[i].Counts)
+	}
+	if responses == 0 {
+		return questions, 0
+	}
+	return questions, roundStat(float64(sum) / float64(responses))
+}
@@ -0,0 +1,309 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GRADE_LAYOUT_VERSION identifies the bucket layout of a GradeDistribution. It changes whenever buckets are added, removed or reordered, so
+// clients can detect a layout they don't understand instead of misreading it.
+const GRADE_LAYOUT_VERSION = "utd14.v1"
+
+// GradeLabels are the letter grades of each bucket of a section's grade_distribution array, in array order.
+var GradeLabels = []string{"A+", "A", "A-", "B+", "B", "B-", "C+", "C", "C-", "D+", "D", "D-", "F", "W"}
+
+// GradeDistribution is the number of students who received each letter grade, with one named bucket per grade instead of a positional array.
+type GradeDistribution struct {
+	Layout  string `bson:"layout" json:"layout"`
+	A_plus  int    `bson:"a_plus" json:"a_plus"`
+	A       int    `bson:"a" json:"a"`
+	A_minus int    `bson:"a_minus" json:"a_minus"`
+	B_plus  int    `bson:"b_plus" json:"b_plus"`
+	B       int    `bson:"b" json:"b"`
+	B_minus int    `bson:"b_minus" json:"b_minus"`
+	C_plus  int    `bson:"c_plus" json:"c_plus"`
+	C       int    `bson:"c" json:"c"`
+	C_minus int    `bson:"c_minus" json:"c_minus"`
+	D_plus  int    `bson:"d_plus" json:"d_plus"`
+	D       int    `bson:"d" json:"d"`
+	D_minus int    `bson:"d_minus" json:"d_minus"`
+	F       int    `bson:"f" json:"f"`
+	W       int    `bson:"w" json:"w"`
+}
+
+// NewGradeDistribution converts a grade_distribution array, ordered as in GradeLabels, into a GradeDistribution. Entries past the end of the
+// layout are ignored and missing entries are counted as zero.
+func NewGradeDistribution(counts []int) GradeDistribution {
+	distribution := GradeDistribution{Layout: GRADE_LAYOUT_VERSION}
+	buckets := distribution.buckets()
+	for i := 0; i < len(counts) && i < len(buckets); i++ {
+		*buckets[i] = counts[i]
+	}
+	return distribution
+}
+
+// ParseGradeDistribution converts a grade_distribution array, which must be in the canonical layout of GradeLabels, into a GradeDistribution.
+// Arrays are only validated, never remapped: one of any other length may just as well be truncated as be in some older layout the grade data
+// source never documented, so it returns an error for it, as it does for a negative count. Such arrays are quarantined, left out of every
+// aggregate and listed by the data-quality report. An empty array is an empty distribution.
+func ParseGradeDistribution(counts []int) (GradeDistribution, error) {
+	distribution := GradeDistribution{Layout: GRADE_LAYOUT_VERSION}
+	if len(counts) == 0 {
+		return distribution, nil
+	}
+
+	if len(counts) != len(GradeLabels) {
+		return distribution, fmt.Errorf("grade distribution has %d entries instead of %d", len(counts), len(GradeLabels))
+	}
+	buckets := distribution.buckets()
+	for i, count := range counts {
+		if count < 0 {
+			return distribution, fmt.Errorf("negative count for grade %s", GradeLabels[i])
+		}
+		*buckets[i] = count
+	}
+	return distribution, nil
+}
+
+// buckets returns pointers to each bucket, ordered as in GradeLabels.
+func (d *GradeDistribution) buckets() []*int {
+	return []*int{
+		&d.A_plus, &d.A, &d.A_minus,
+		&d.B_plus, &d.B, &d.B_minus,
+		&d.C_plus, &d.C, &d.C_minus,
+		&d.D_plus, &d.D, &d.D_minus,
+		&d.F, &d.W,
+	}
+}
+
+// Counts returns the distribution as an array ordered as in GradeLabels.
+func (d GradeDistribution) Counts() []int {
+	buckets := d.buckets()
+	counts := make([]int, len(buckets))
+	for i, bucket := range buckets {
+		counts[i] = *bucket
+	}
+	return counts
+}
+
+// Add combines another distribution into this one.
+func (d *GradeDistribution) Add(other GradeDistribution) {
+	d.Layout = GRADE_LAYOUT_VERSION
+	otherBuckets := other.buckets()
+	for i, bucket := range d.buckets() {
+		*bucket += *otherBuckets[i]
+	}
+}
+
+// SemesterGrades is the combined grade distribution and statistics of every matching section in one academic session.
+type SemesterGrades struct {
+	Academic_session string `bson:"academic_session" json:"academic_session"`
+	GradeSummary     `bson:",inline" json:",inline"`
+}
+
+// GradePoints maps each graded bucket of GradeLabels to its grade points on UTD's 4.0 scale. W is the last bucket in GradeLabels and has no
+// entry here: withdrawals carry no grade points and are left out of GPA calculations.
+var GradePoints = []float64{4.00, 4.00, 3.67, 3.33, 3.00, 2.67, 2.33, 2.00, 1.67, 1.33, 1.00, 0.67, 0.00}
+
+// GradeStats are statistics computed from a grade distribution using GradePoints.
+//
+// Fields:
+//
+//	Mean_gpa:       The average grade points of every graded student (withdrawals excluded).
+//	Median_grade:   The letter grade of the median graded student, empty when no students were graded.
+//	Percent_a:      The percentage of all students who received an A+, A or A-.
+//	Pass_rate:      The percentage of all students who received a D- or better.
+//	Dfw_rate:       The percentage of all students who received a D+, D, D-, F or W.
+//	Total_students: The number of students in the distribution, including withdrawals.
+//	Sample_count:   The number of sections combined into the distribution.
+type GradeStats struct {
+	Mean_gpa       float64 `bson:"mean_gpa" json:"mean_gpa"`
+	Median_grade   string  `bson:"median_grade" json:"median_grade"`
+	Percent_a      float64 `bson:"percent_a" json:"percent_a"`
+	Pass_rate      float64 `bson:"pass_rate" json:"pass_rate"`
+	Dfw_rate       float64 `bson:"dfw_rate" json:"dfw_rate"`
+	Total_students int     `bson:"total_students" json:"total_students"`
+	Sample_count   int     `bson:"sample_count" json:"sample_count"`
+}
+
+// Stats computes GradeStats for the distribution. sampleCount is the number of sections that were combined into it.
+func (d GradeDistribution) Stats(sampleCount int) GradeStats {
+	counts := d.Counts()
+	stats := GradeStats{Sample_count: sampleCount}
+
+	graded, points := 0, 0.0
+	for i, count := range counts {
+		stats.Total_students += count
+		if i < len(GradePoints) {
+			graded += count
+			points += GradePoints[i] * float64(count)
+		}
+	}
+	if stats.Total_students == 0 {
+		return stats
+	}
+
+	if graded > 0 {
+		stats.Mean_gpa = roundStat(points / float64(graded))
+
+		// Walk the graded buckets until reaching the middle student
+		median, seen := (graded+1)/2, 0
+		for i := range GradePoints {
+			seen += counts[i]
+			if seen >= median {
+				stats.Median_grade = GradeLabels[i]
+				break
+			}
+		}
+	}
+
+	total := float64(stats.Total_students)
+	stats.Percent_a = roundStat(float64(d.A_plus+d.A+d.A_minus) / total * 100)
+	stats.Pass_rate = roundStat(float64(stats.Total_students-d.F-d.W) / total * 100)
+	stats.Dfw_rate = roundStat(float64(d.D_plus+d.D+d.D_minus+d.F+d.W) / total * 100)
+
+	return stats
+}
+
+// roundStat rounds a statistic to two decimal places.
+func roundStat(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// GradeSummary is a grade distribution together with the statistics computed from it.
+type GradeSummary struct {
+	Grade_distribution GradeDistribution `bson:"grade_distribution" json:"grade_distribution"`
+	Stats              GradeStats        `bson:"stats" json:"stats"`
+}
+
+// NewGradeSummary computes the statistics for a distribution combined from sampleCount sections.
+func NewGradeSummary(distribution GradeDistribution, sampleCount int) GradeSummary {
+	return GradeSummary{Grade_distribution: distribution, Stats: distribution.Stats(sampleCount)}
+}
+
+// Metric returns the statistic with the given JSON name, e.g. "mean_gpa" or "dfw_rate", so statistics can be selected by query parameters.
+func (s GradeStats) Metric(name string) (float64, bool) {
+	switch name {
+	case "mean_gpa":
+		return s.Mean_gpa, true
+	case "percent_a":
+		return s.Percent_a, true
+	case "pass_rate":
+		return s.Pass_rate, true
+	case "dfw_rate":
+		return s.Dfw_rate, true
+	case "total_students":
+		return float64(s.Total_students), true
+	case "sample_count":
+		return float64(s.Sample_count), true
+	default:
+		return 0, false
+	}
+}
+
+// ProfessorCandidate identifies one of several professors matching a name, so clients can repeat a query with the professor's id instead.
+type ProfessorCandidate struct {
+	Id          primitive.ObjectID `bson:"_id" json:"_id"`
+	First_name  string             `bson:"first_name" json:"first_name"`
+	Last_name   string             `bson:"last_name" json:"last_name"`
+	Titles      []string           `bson:"titles" json:"titles"`
+	Email       string             `bson:"email" json:"email"`
+	Profile_uri string             `bson:"profile_uri" json:"profile_uri"`
+}
+
+// GradeFilters are the filters grade distributions can be aggregated by. Any subset of them can be combined.
+//
+// Fields:
+//
+//	Prefix, Number:      The course's subject prefix and official number.
+//	School:              The school offering the course.
+//	Level:               The course level: "lower", "upper" or "graduate". Ignored when Number is given.
+//	First_name, Last_name: The professor's name, used only when Professor_id isn't given.
+//	Professor_id:        The professor's id.
+//	Section_number:      The number of the section.
+//	Core_flag:           A core requirement code the section fulfills.
+//	Term, From, To:      A single academic session, or an inclusive range of them.
+//	Exclude_summer:      Leave out summer academic sessions.
+type GradeFilters struct {
+	Prefix         string `form:"prefix" json:"prefix"`
+	Number         string `form:"number" json:"number"`
+	School         string `form:"school" json:"school"`
+	Level          string `form:"level" json:"level"`
+	First_name     string `form:"first_name" json:"first_name"`
+	Last_name      string `form:"last_name" json:"last_name"`
+	Professor_id   string `form:"professor_id" json:"professor_id"`
+	Section_number string `form:"section_number" json:"section_number"`
+	Core_flag      string `form:"core_flag" json:"core_flag"`
+	Term           string `form:"term" json:"term"`
+	From           string `form:"from" json:"from"`
+	To             string `form:"to" json:"to"`
+	Exclude_summer bool   `form:"exclude_summer" json:"exclude_summer"`
+}
+
+// CourseLevels maps each course level to a pattern on the course number: the first digit of a course number is its level.
+var CourseLevels = map[string]string{
+	"lower":    "^[12]",
+	"upper":    "^[34]",
+	"graduate": "^[5-9]",
+}
+
+// GradeRanking is one entry of a grade leaderboard, ranking either a course or a professor by a grade statistic. Only the fields describing
+// what is ranked are included: the course fields when ranking courses, and the professor fields when ranking professors.
+//
+// Fields:
+//
+//	Rank:   The position in the leaderboard, starting at 1. Entries with equal values share a rank.
+//	Value:  The value of the statistic the leaderboard is ranked by.
+//	Grades: The combined grade distribution and statistics the value was taken from.
+type GradeRanking struct {
+	Rank                int                 `bson:"rank" json:"rank"`
+	Value               float64             `bson:"value" json:"value"`
+	Subject_prefix      string              `bson:"subject_prefix,omitempty" json:"subject_prefix,omitempty"`
+	Course_number       string              `bson:"course_number,omitempty" json:"course_number,omitempty"`
+	Title               string              `bson:"title,omitempty" json:"title,omitempty"`
+	Professor_reference *primitive.ObjectID `bson:"professor_reference,omitempty" json:"professor_reference,omitempty"`
+	First_name          string              `bson:"first_name,omitempty" json:"first_name,omitempty"`
+	Last_name           string              `bson:"last_name,omitempty" json:"last_name,omitempty"`
+	Grades              GradeSummary        `bson:"grades" json:"grades"`
+}
+
+// GRADE_QUARANTINED is the status of a grade_distribution array that isn't in the canonical layout or has a negative count, and so is left out of
+// every aggregate.
+const GRADE_QUARANTINED = "quarantined"
+
+// GradeQualityIssue is a section whose grade_distribution array is quarantined.
+type GradeQualityIssue struct {
+	Section_reference  primitive.ObjectID `bson:"_id" json:"section_reference"`
+	Course_reference   primitive.ObjectID `bson:"course_reference" json:"course_reference"`
+	Section_number     string             `bson:"section_number" json:"section_number"`
+	Academic_session   string             `bson:"academic_session" json:"academic_session"`
+	Grade_distribution []int              `bson:"grade_distribution" json:"grade_distribution"`
+	Length             int                `bson:"length" json:"length"`
+	Status             string             `bson:"status" json:"status"`
+	Reason             string             `bson:"reason" json:"reason"`
+}
+
+// The kinds of key a precomputed grade aggregate is stored under. Every aggregate is for a single academic session.
+const (
+	GRADE_AGGREGATE_COURSE           = "course"
+	GRADE_AGGREGATE_PROFESSOR        = "professor"
+	GRADE_AGGREGATE_COURSE_PROFESSOR = "course_professor"
+)
+
+// GradeAggregate is the summed grade distribution of every section of a course, a professor, or a professor's sections of a course in one
+// academic session, as stored in the grade_aggregates collection. Courses are identified by subject prefix and number, so every catalog year
+// of a course shares its aggregates. The distribution is always in the canonical layout.
+type GradeAggregate struct {
+	Kind                string               `bson:"kind" json:"kind"`
+	Subject_prefix      string               `bson:"subject_prefix,omitempty" json:"subject_prefix,omitempty"`
+	Course_number       string               `bson:"course_number,omitempty" json:"course_number,omitempty"`
+	Professor_reference *primitive.ObjectID  `bson:"professor_reference,omitempty" json:"professor_reference,omitempty"`
+	Academic_session    string               `bson:"academic_session" json:"academic_session"`
+	Grade_distribution  []int                `bson:"grade_distribution" json:"grade_distribution"`
+	Sections            int                  `bson:"sections" json:"sections"`
+	Professors          []primitive.ObjectID `bson:"professors" json:"professors"`
+	Refreshed_at        time.Time            `bson:"refreshed_at" json:"refreshed_at"`
+}
@@ -97,7 +97,8 @@ type Professor struct {
 	Sections     []primitive.ObjectID `bson:"sections" json:"sections" schema:"-"`
 }
 
-// Organization represents the academic organization or group.
+// Organization represents the academic organization or group. The API serves its picture separately at Picture_uri rather than inlining
+// Picture_data, so Has_picture is computed when reading an organization instead of the data itself.
 type Organization struct {
 	Id             primitive.ObjectID `bson:"_id" json:"_id"`
 	Title          string             `bson:"title" json:"title"`
@@ -105,10 +106,12 @@ type Organization struct {
 	Categories     []string           `bson:"categories" json:"categories"`
 	President_name string             `bson:"president_name" json:"president_name"`
 	Emails         []string           `bson:"emails" json:"emails"`
-	Picture_data   string             `bson:"picture_data" json:"picture_data"`
+	Picture_data   string             `bson:"picture_data,omitempty" json:"picture_data,omitempty"`
+	Has_picture    bool               `bson:"has_picture,omitempty" json:"-"`
+	Picture_uri    string             `bson:"-" json:"picture_uri,omitempty"`
 }
 
-// Event represents the event related to courses or organizations.
+// Event represents the event related to courses or organizations. Updated_at is when the event was last changed, if the uploader recorded it.
 type Event struct {
 	Id                 primitive.ObjectID `bson:"_id" json:"_id"`
 	Summary            string             `bson:"summary" json:"summary"`
@@ -125,6 +128,7 @@ type Event struct {
 	ContactName        string             `bson:"contact_name" json:"contact_name"`
 	ContactEmail       string             `bson:"contact_email" json:"contact_email"`
 	ContactPhoneNumber string             `bson:"contact_phone_number" json:"contact_phone_number"`
+	Updated_at         time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
 }
 
 // 5 Level Likert Item scale for evaluation responses
@@ -152,9 +156,21 @@ type EvaluationField struct {
 	Summary     EvaluationSummary              `bson:"summary" json:"summary"`
 }
 
+// Where stored evaluations came from
+const (
+	EVALUATION_SOURCE_COURSEBOOK = "coursebook"
+	EVALUATION_SOURCE_IMPORT     = "import"
+)
+
+// Evaluation is the UES report of a section, stored under the section's id. Evaluations imported in bulk have no fetch time (nil) and never go stale,
+// while those scraped from coursebook record it so they can be refreshed once stale. Sections without a report are stored as unavailable, so they aren't
+// scraped again on every request.
 type Evaluation struct {
 	Id                   primitive.ObjectID `bson:"_id" json:"_id"`
 	CourseExperience     []EvaluationField  `bson:"course_experience" json:"course_experience"`
 	InstructorExperience []EvaluationField  `bson:"instructor_experience" json:"instructor_experience"`
 	StudentExperience    []EvaluationField  `bson:"student_experience" json:"student_experience"`
+	Source               string             `bson:"source,omitempty" json:"source,omitempty"`
+	Fetched_at           *time.Time         `bson:"fetched_at,omitempty" json:"fetched_at,omitempty"`
+	Unavailable          bool               `bson:"unavailable,omitempty" json:"-"`
 }
@@ -0,0 +1,272 @@
+package schema
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Year parities an offering pattern can be restricted to.
+const (
+	EVERY_YEAR = "every"
+	EVEN_YEARS = "even"
+	ODD_YEARS  = "odd"
+)
+
+// How many terms of history are considered when scoring how reliably a course follows its offering pattern.
+const offeringHistoryWindow = 9
+
+// How many terms ahead of the current term a prediction is allowed to look.
+const offeringPredictionHorizon = 12
+
+// OfferingPattern is the structured form of a course's catalog offering frequency.
+//
+// Fields:
+//
+//	Raw:     The offering frequency exactly as it appears in the catalog.
+//	Seasons: The seasons the course is offered in. Empty when the catalog doesn't say.
+//	Years:   Which years the course is offered in: "every", "even" or "odd".
+//	Regular: False when the catalog only promises the course on demand or on rotation.
+type OfferingPattern struct {
+	Raw     string   `bson:"raw" json:"raw"`
+	Seasons []Season `bson:"seasons" json:"seasons"`
+	Years   string   `bson:"years" json:"years"`
+	Regular bool     `bson:"regular" json:"regular"`
+}
+
+// The UTD catalog abbreviates offering frequencies to a single letter code.
+var offeringCodes = map[string]OfferingPattern{
+	"S": {Seasons: []Season{FALL, SPRING}, Years: EVERY_YEAR, Regular: true}, // every long semester
+	"Y": {Years: EVERY_YEAR, Regular: true},                                  // once a year
+	"R": {Years: EVERY_YEAR, Regular: false},                                 // on rotation
+	"T": {Years: EVERY_YEAR, Regular: false},                                 // as scheduled
+}
+
+var (
+	fallPattern      = regexp.MustCompile(`\bfall\b`)
+	springPattern    = regexp.MustCompile(`\bspring\b`)
+	summerPattern    = regexp.MustCompile(`\bsummers?\b`)
+	everyTermPattern = regexp.MustCompile(`\b(every|each) (long )?(semester|term)\b`)
+	evenPattern      = regexp.MustCompile(`\beven\b`)
+	oddPattern       = regexp.MustCompile(`\bodd\b`)
+	irregularPattern = regexp.MustCompile(`\b(demand|rotat\w*|scheduled|occasional\w*|irregular\w*|needed|varies)\b`)
+)
+
+// ParseOfferingFrequency converts a catalog offering frequency, either a single letter code such as "S" or free text such as
+// "Fall and Spring, even years", into an OfferingPattern. Unrecognized text produces an irregular pattern with no seasons.
+func ParseOfferingFrequency(raw string) OfferingPattern {
+	trimmed := strings.TrimSpace(raw)
+
+	if pattern, ok := offeringCodes[strings.ToUpper(trimmed)]; ok {
+		pattern.Raw = raw
+		return pattern
+	}
+
+	text := strings.ToLower(trimmed)
+	pattern := OfferingPattern{Raw: raw, Years: EVERY_YEAR}
+
+	if everyTermPattern.MatchString(text) {
+		pattern.Seasons = append(pattern.Seasons, FALL, SPRING)
+	} else {
+		if fallPattern.MatchString(text) {
+			pattern.Seasons = append(pattern.Seasons, FALL)
+		}
+		if springPattern.MatchString(text) {
+			pattern.Seasons = append(pattern.Seasons, SPRING)
+		}
+	}
+	if summerPattern.MatchString(text) {
+		pattern.Seasons = append(pattern.Seasons, SUMMER)
+	}
+
+	if evenPattern.MatchString(text) {
+		pattern.Years = EVEN_YEARS
+	} else if oddPattern.MatchString(text) {
+		pattern.Years = ODD_YEARS
+	}
+
+	pattern.Regular = len(pattern.Seasons) > 0 && !irregularPattern.MatchString(text)
+
+	return pattern
+}
+
+// Includes reports whether the pattern schedules the course in the given term.
+func (p OfferingPattern) Includes(t Term) bool {
+	switch p.Years {
+	case EVEN_YEARS:
+		if t.Year%2 != 0 {
+			return false
+		}
+	case ODD_YEARS:
+		if t.Year%2 == 0 {
+			return false
+		}
+	}
+	for _, season := range p.Seasons {
+		if season == t.Season {
+			return true
+		}
+	}
+	return false
+}
+
+// OfferingMismatch describes a term where the catalog and the sections on record disagree.
+//
+// Fields:
+//
+//	Term: The academic session name of the term, e.g. "22F".
+//	Kind: "missing" when the catalog schedules the course but no sections were offered,
+//	      "unexpected" when sections were offered in a term the catalog doesn't schedule.
+type OfferingMismatch struct {
+	Term string `bson:"term" json:"term"`
+	Kind string `bson:"kind" json:"kind"`
+}
+
+// CourseOfferings summarizes when a course has been offered and when it's likely to be offered next.
+//
+// Fields:
+//
+//	Subject_prefix:     The course's subject prefix.
+//	Course_number:      The course's official number.
+//	Offering_frequency: The catalog offering frequency, parsed into an OfferingPattern.
+//	Past_terms:         Every term with at least one section on record, oldest first.
+//	Predicted_next:     The next term the course is expected to be offered, empty when no prediction can be made.
+//	Confidence:         How reliable the prediction is, from 0 to 1.
+//	Mismatches:         Terms where the catalog and the sections on record disagree.
+type CourseOfferings struct {
+	Course_reference   primitive.ObjectID `bson:"course_reference" json:"course_reference"`
+	Subject_prefix     string             `bson:"subject_prefix" json:"subject_prefix"`
+	Course_number      string             `bson:"course_number" json:"course_number"`
+	Offering_frequency OfferingPattern    `bson:"offering_frequency" json:"offering_frequency"`
+	Past_terms         []string           `bson:"past_terms" json:"past_terms"`
+	Predicted_next     string             `bson:"predicted_next" json:"predicted_next"`
+	Confidence         float64            `bson:"confidence" json:"confidence"`
+	Mismatches         []OfferingMismatch `bson:"mismatches" json:"mismatches"`
+}
+
+// NewCourseOfferings compares a catalog offering pattern with the terms a course was actually offered in and predicts the next term after current.
+// When the catalog doesn't name any seasons the pattern is inferred from history instead, and no mismatches are reported.
+func NewCourseOfferings(pattern OfferingPattern, pastTerms []Term, current Term) CourseOfferings {
+	offerings := CourseOfferings{
+		Offering_frequency: pattern,
+		Past_terms:         []string{},
+		Mismatches:         []OfferingMismatch{},
+	}
+
+	sort.Slice(pastTerms, func(i, j int) bool { return pastTerms[i].Before(pastTerms[j]) })
+
+	offered := make(map[Term]bool)
+	for _, term := range pastTerms {
+		if !offered[term] {
+			offered[term] = true
+			offerings.Past_terms = append(offerings.Past_terms, term.String())
+		}
+	}
+
+	// Sections already on record for a future term are a certain prediction
+	for _, term := range pastTerms {
+		if current.Before(term) {
+			offerings.Predicted_next = term.String()
+			offerings.Confidence = 1
+			break
+		}
+	}
+
+	expected := pattern
+	if len(pattern.Seasons) == 0 {
+		expected = inferOfferingPattern(pastTerms)
+	} else if len(pastTerms) > 0 {
+		// Report where history contradicts the catalog
+		for term := pastTerms[0]; !current.Before(term); term = term.Next() {
+			if pattern.Includes(term) && !offered[term] {
+				offerings.Mismatches = append(offerings.Mismatches, OfferingMismatch{Term: term.String(), Kind: "missing"})
+			}
+		}
+		for _, term := range pastTerms {
+			if !pattern.Includes(term) {
+				offerings.Mismatches = append(offerings.Mismatches, OfferingMismatch{Term: term.String(), Kind: "unexpected"})
+			}
+		}
+	}
+
+	if offerings.Predicted_next != "" || len(expected.Seasons) == 0 {
+		return offerings
+	}
+
+	for term, i := current.Next(), 0; i < offeringPredictionHorizon; term, i = term.Next(), i+1 {
+		if expected.Includes(term) {
+			offerings.Predicted_next = term.String()
+			break
+		}
+	}
+
+	// Score the prediction by how often the course ran when the pattern said it would over recent history
+	if len(pastTerms) == 0 {
+		offerings.Confidence = 0.25
+	} else {
+		start := pastTerms[0]
+		for start.Index() < current.Index()-offeringHistoryWindow {
+			start = start.Next()
+		}
+		expectedCount, hits := 0, 0
+		for term := start; !current.Before(term); term = term.Next() {
+			if expected.Includes(term) {
+				expectedCount++
+				if offered[term] {
+					hits++
+				}
+			}
+		}
+		offerings.Confidence = 0.5
+		if expectedCount > 0 {
+			offerings.Confidence = float64(hits) / float64(expectedCount)
+		}
+	}
+	if !pattern.Regular {
+		offerings.Confidence /= 2
+	}
+	offerings.Confidence = math.Round(offerings.Confidence*100) / 100
+
+	return offerings
+}
+
+// inferOfferingPattern builds an offering pattern from history alone: a season is included when the course ran in it in at least half of the years
+// on record, and the pattern is limited to even or odd years when every offering over at least three years shares the same parity.
+func inferOfferingPattern(pastTerms []Term) OfferingPattern {
+	pattern := OfferingPattern{Years: EVERY_YEAR, Regular: true}
+	if len(pastTerms) == 0 {
+		return pattern
+	}
+
+	firstYear, lastYear := pastTerms[0].Year, pastTerms[len(pastTerms)-1].Year
+	span := lastYear - firstYear + 1
+
+	seasonYears := make(map[Season]map[int]bool)
+	parities := make(map[int]bool)
+	for _, term := range pastTerms {
+		if seasonYears[term.Season] == nil {
+			seasonYears[term.Season] = make(map[int]bool)
+		}
+		seasonYears[term.Season][term.Year] = true
+		parities[term.Year%2] = true
+	}
+
+	for _, season := range []Season{SPRING, SUMMER, FALL} {
+		if len(seasonYears[season])*2 >= span {
+			pattern.Seasons = append(pattern.Seasons, season)
+		}
+	}
+
+	if span >= 3 && len(parities) == 1 {
+		if parities[0] {
+			pattern.Years = EVEN_YEARS
+		} else {
+			pattern.Years = ODD_YEARS
+		}
+	}
+
+	return pattern
+}
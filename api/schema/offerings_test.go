@@ -0,0 +1,125 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOfferingFrequency(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want OfferingPattern
+	}{
+		// Letter codes
+		{"S", OfferingPattern{Seasons: []Season{FALL, SPRING}, Years: EVERY_YEAR, Regular: true}},
+		{" s ", OfferingPattern{Seasons: []Season{FALL, SPRING}, Years: EVERY_YEAR, Regular: true}},
+		{"Y", OfferingPattern{Years: EVERY_YEAR, Regular: true}},
+		{"R", OfferingPattern{Years: EVERY_YEAR, Regular: false}},
+		{"T", OfferingPattern{Years: EVERY_YEAR, Regular: false}},
+		// Free text
+		{"Fall and Spring, even years", OfferingPattern{Seasons: []Season{FALL, SPRING}, Years: EVEN_YEARS, Regular: true}},
+		{"Spring only, odd years", OfferingPattern{Seasons: []Season{SPRING}, Years: ODD_YEARS, Regular: true}},
+		{"Every long semester", OfferingPattern{Seasons: []Season{FALL, SPRING}, Years: EVERY_YEAR, Regular: true}},
+		{"Each term and summers", OfferingPattern{Seasons: []Season{FALL, SPRING, SUMMER}, Years: EVERY_YEAR, Regular: true}},
+		{"SUMMER", OfferingPattern{Seasons: []Season{SUMMER}, Years: EVERY_YEAR, Regular: true}},
+		{"Fall; on demand", OfferingPattern{Seasons: []Season{FALL}, Years: EVERY_YEAR, Regular: false}},
+		{"Spring, as scheduled", OfferingPattern{Seasons: []Season{SPRING}, Years: EVERY_YEAR, Regular: false}},
+		// Words must stand alone
+		{"Fallow", OfferingPattern{Years: EVERY_YEAR, Regular: false}},
+		// Unknown input
+		{"", OfferingPattern{Years: EVERY_YEAR, Regular: false}},
+		{"X", OfferingPattern{Years: EVERY_YEAR, Regular: false}},
+		{"Offered rarely", OfferingPattern{Years: EVERY_YEAR, Regular: false}},
+	}
+	for _, test := range tests {
+		test.want.Raw = test.raw
+		if got := ParseOfferingFrequency(test.raw); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %+v, want %+v", test.raw, got, test.want)
+		}
+	}
+}
+
+func TestOfferingPatternIncludes(t *testing.T) {
+	pattern := ParseOfferingFrequency("Fall and Spring, even years")
+	tests := map[string]bool{"22F": true, "22S": true, "22U": false, "23F": false, "23S": false}
+	for name, want := range tests {
+		term, _ := ParseTerm(name)
+		if got := pattern.Includes(term); got != want {
+			t.Errorf("%s: got %t, want %t", name, got, want)
+		}
+	}
+	if ParseOfferingFrequency("Y").Includes(Term{Year: 2023, Season: FALL}) {
+		t.Errorf("a pattern without seasons includes a term")
+	}
+}
+
+// parseTerms parses academic session names, failing the test on invalid ones.
+func parseTerms(t *testing.T, names ...string) []Term {
+	t.Helper()
+	terms := make([]Term, len(names))
+	for i, name := range names {
+		term, err := ParseTerm(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		terms[i] = term
+	}
+	return terms
+}
+
+func TestNewCourseOfferings(t *testing.T) {
+	current := Term{Year: 2023, Season: FALL}
+	tests := []struct {
+		name           string
+		frequency      string
+		past           []string
+		wantNext       string
+		wantConfidence float64
+		wantMismatches []OfferingMismatch
+	}{
+		{"follows the catalog", "Fall", []string{"23F", "21F", "22F"}, "24F", 1, nil},
+		{
+			"strays from the catalog", "Fall", []string{"21F", "22S", "23F"}, "24F", 0.67,
+			[]OfferingMismatch{{Term: "22F", Kind: "missing"}, {Term: "22S", Kind: "unexpected"}},
+		},
+		{"only on demand", "Fall, on demand", []string{"21F", "22F", "23F"}, "24F", 0.5, nil},
+		{"section already scheduled", "U", []string{"23U", "24U"}, "24U", 1, nil},
+		{"no history", "S", nil, "24S", 0.25, nil},
+		{
+			"only older history", "Fall", []string{"15F", "16F"}, "24F", 0,
+			[]OfferingMismatch{
+				{Term: "17F", Kind: "missing"}, {Term: "18F", Kind: "missing"}, {Term: "19F", Kind: "missing"}, {Term: "20F", Kind: "missing"},
+				{Term: "21F", Kind: "missing"}, {Term: "22F", Kind: "missing"}, {Term: "23F", Kind: "missing"},
+			},
+		},
+		{"odd years", "Fall, odd years", []string{"21F", "23F"}, "25F", 1, nil},
+		// Without seasons in the catalog, the pattern is inferred from history and nothing is a mismatch
+		{"inferred every year", "Y", []string{"20S", "21S", "22S", "23S"}, "24S", 1, nil},
+		{"inferred even years", "Y", []string{"18F", "20F", "22F"}, "24F", 1, nil},
+		// Unrecognized text is irregular, so the inferred pattern counts for half
+		{"inferred from unrecognized text", "", []string{"18F", "20F", "22F"}, "24F", 0.5, nil},
+		{"inferred, irregular", "R", []string{"20S", "21S", "22S", "23S"}, "24S", 0.5, nil},
+		{"nothing to go on", "", nil, "", 0, nil},
+	}
+	for _, test := range tests {
+		offerings := NewCourseOfferings(ParseOfferingFrequency(test.frequency), parseTerms(t, test.past...), current)
+		if offerings.Predicted_next != test.wantNext || offerings.Confidence != test.wantConfidence {
+			t.Errorf("%s: got %q with confidence %v, want %q with confidence %v",
+				test.name, offerings.Predicted_next, offerings.Confidence, test.wantNext, test.wantConfidence)
+		}
+		if test.wantMismatches == nil {
+			test.wantMismatches = []OfferingMismatch{}
+		}
+		if !reflect.DeepEqual(offerings.Mismatches, test.wantMismatches) {
+			t.Errorf("%s: got mismatches %+v, want %+v", test.name, offerings.Mismatches, test.wantMismatches)
+		}
+	}
+}
+
+func TestNewCourseOfferingsPastTerms(t *testing.T) {
+	offerings := NewCourseOfferings(ParseOfferingFrequency("S"), parseTerms(t, "23F", "22S", "23F", "22F"), Term{Year: 2023, Season: FALL})
+	want := []string{"22S", "22F", "23F"}
+	if !reflect.DeepEqual(offerings.Past_terms, want) {
+		t.Errorf("got past terms %v, want %v sorted without duplicates", offerings.Past_terms, want)
+	}
+}
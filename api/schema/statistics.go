@@ -0,0 +1,161 @@
+package schema
+
+import (
+	"math"
+)
+
+// studentTPValue returns the two-sided p-value of a t statistic under Student's t distribution with df degrees of freedom.
+func studentTPValue(t float64, df float64) float64 {
+	return regularizedIncompleteBeta(df/2, 0.5, df/(df+t*t))
+}
+
+// studentTCritical returns the t statistic whose two-sided p-value is alpha under Student's t distribution with df degrees of freedom, found by
+// bisection since the p-value falls as the statistic grows.
+func studentTCritical(alpha float64, df float64) float64 {
+	low, high := 0.0, 1000.0
+	for i := 0; i < 100; i++ {
+		mid := (low + high) / 2
+		if studentTPValue(mid, df) > alpha {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2
+}
+
+// normalPValue returns the two-sided p-value of a z statistic under the standard normal distribution.
+func normalPValue(z float64) float64 {
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// chiSquarePValue returns the probability of a chi-square statistic at least this large with df degrees of freedom.
+func chiSquarePValue(statistic float64, df float64) float64 {
+	return regularizedUpperGamma(df/2, statistic/2)
+}
+
+// regularizedUpperGamma evaluates the regularized upper incomplete gamma function Q(s, x), using its series expansion below s+1 and its
+// continued fraction expansion above.
+func regularizedUpperGamma(s float64, x float64) float64 {
+	const maxIterations = 500
+	const epsilon = 3e-14
+	const tiny = 1e-300
+
+	if x <= 0 {
+		return 1
+	}
+	lgammaS, _ := math.Lgamma(s)
+	front := math.Exp(s*math.Log(x) - x - lgammaS)
+
+	if x < s+1 {
+		term := 1 / s
+		sum := term
+		for n := 1.0; n <= maxIterations; n++ {
+			term *= x / (s + n)
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*epsilon {
+				break
+			}
+		}
+		return 1 - front*sum
+	}
+
+	// Modified Lentz method
+	b := x + 1 - s
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1.0; n <= maxIterations; n++ {
+		an := -n * (n - s)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return front * h
+}
+
+// regularizedIncompleteBeta evaluates the regularized incomplete beta function I_x(a, b) using its continued fraction expansion.
+func regularizedIncompleteBeta(a float64, b float64, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	lgammaAB, _ := math.Lgamma(a + b)
+	lgammaA, _ := math.Lgamma(a)
+	lgammaB, _ := math.Lgamma(b)
+	front := math.Exp(lgammaAB - lgammaA - lgammaB + a*math.Log(x) + b*math.Log(1-x))
+
+	// The continued fraction converges quickly only on one side of the mean, so use the symmetry I_x(a, b) = 1 - I_1-x(b, a) on the other
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+// betaContinuedFraction evaluates the continued fraction of the incomplete beta function with the modified Lentz method.
+func betaContinuedFraction(a float64, b float64, x float64) float64 {
+	const maxIterations = 200
+	const epsilon = 3e-14
+	const tiny = 1e-300
+
+	clamp := func(v float64) float64 {
+		if math.Abs(v) < tiny {
+			return tiny
+		}
+		return v
+	}
+
+	c := 1.0
+	d := 1 / clamp(1-(a+b)*x/(a+1))
+	h := d
+	for m := 1.0; m <= maxIterations; m++ {
+		// Even step
+		numerator := m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		d = 1 / clamp(1+numerator*d)
+		c = clamp(1 + numerator/c)
+		h *= d * c
+
+		// Odd step
+		numerator = -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		d = 1 / clamp(1+numerator*d)
+		c = clamp(1 + numerator/c)
+		delta := d * c
+		h *= delta
+
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
+
+// PercentileRank returns the percentage of values below value, counting values equal to it as half.
+func PercentileRank(values []float64, value float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	below := 0.0
+	for _, other := range values {
+		if other < value {
+			below++
+		} else if other == value {
+			below += 0.5
+		}
+	}
+	return roundStat(below / float64(len(values)) * 100)
+}
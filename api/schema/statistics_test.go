@@ -0,0 +1,148 @@
+package schema
+
+import (
+	"math"
+	"testing"
+)
+
+// closeTo reports whether got is within tolerance of want.
+func closeTo(got float64, want float64, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func TestRegularizedIncompleteBeta(t *testing.T) {
+	tests := []struct {
+		a, b, x, want float64
+	}{
+		// Closed forms: I_x(1, 1) = x, I_x(a, 1) = x^a, I_x(1, b) = 1 - (1-x)^b, and I_1/2(a, a) = 1/2 by symmetry
+		{1, 1, 0.3, 0.3},
+		{3, 1, 0.6, 0.216},
+		{1, 4, 0.2, 1 - math.Pow(0.8, 4)},
+		{7.5, 7.5, 0.5, 0.5},
+		{0.5, 0.5, 0.5, 0.5},
+		// On either side of the mean, where the symmetry relation is used
+		{2, 3, 0.1, 0.0523},
+		{2, 3, 0.9, 0.9963},
+		// Outside (0, 1)
+		{2, 3, 0, 0},
+		{2, 3, -1, 0},
+		{2, 3, 1, 1},
+	}
+	for _, test := range tests {
+		if got := regularizedIncompleteBeta(test.a, test.b, test.x); !closeTo(got, test.want, 1e-4) {
+			t.Errorf("I_%v(%v, %v): got %v, want %v", test.x, test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestRegularizedUpperGamma(t *testing.T) {
+	tests := []struct {
+		s, x, want float64
+	}{
+		// Q(1, x) = e^-x, on both sides of s+1 where the series gives way to the continued fraction
+		{1, 0.5, math.Exp(-0.5)},
+		{1, 5, math.Exp(-5)},
+		// Q(2, x) = (1 + x) e^-x
+		{2, 1, 2 * math.Exp(-1)},
+		{2, 10, 11 * math.Exp(-10)},
+		// Q(1/2, x) = erfc(sqrt(x))
+		{0.5, 0.25, math.Erfc(0.5)},
+		{0.5, 4, math.Erfc(2)},
+		{3, 0, 1},
+		{3, -2, 1},
+	}
+	for _, test := range tests {
+		if got := regularizedUpperGamma(test.s, test.x); !closeTo(got, test.want, 1e-10) {
+			t.Errorf("Q(%v, %v): got %v, want %v", test.s, test.x, got, test.want)
+		}
+	}
+}
+
+func TestStudentT(t *testing.T) {
+	tests := []struct {
+		t, df, want float64
+	}{
+		{2, 10, 0.073388},
+		{-2, 10, 0.073388},
+		{1, 1, 0.5},
+		{0, 5, 1},
+		{1.959964, 1e6, 0.05},
+	}
+	for _, test := range tests {
+		if got := studentTPValue(test.t, test.df); !closeTo(got, test.want, 1e-5) {
+			t.Errorf("p-value of t = %v with %v degrees of freedom: got %v, want %v", test.t, test.df, got, test.want)
+		}
+	}
+
+	critical := []struct {
+		alpha, df, want float64
+	}{
+		{0.05, 10, 2.228139},
+		{0.05, 1, 12.706205},
+		{0.01, 30, 2.749996},
+		{0.05, 1e6, 1.959966},
+	}
+	for _, test := range critical {
+		if got := studentTCritical(test.alpha, test.df); !closeTo(got, test.want, 1e-4) {
+			t.Errorf("critical t at %v with %v degrees of freedom: got %v, want %v", test.alpha, test.df, got, test.want)
+		}
+	}
+}
+
+func TestChiSquarePValue(t *testing.T) {
+	tests := []struct {
+		statistic, df, want float64
+	}{
+		{3.841459, 1, 0.05},
+		{11.070498, 5, 0.05},
+		{30, 10, 0.000857},
+		{0.5, 3, 0.918891},
+		{0, 4, 1},
+	}
+	for _, test := range tests {
+		if got := chiSquarePValue(test.statistic, test.df); !closeTo(got, test.want, 1e-6) {
+			t.Errorf("p-value of %v with %v degrees of freedom: got %v, want %v", test.statistic, test.df, got, test.want)
+		}
+	}
+}
+
+func TestNormalPValue(t *testing.T) {
+	tests := []struct {
+		z, want float64
+	}{
+		{0, 1},
+		{1.959964, 0.05},
+		{-1.959964, 0.05},
+		{2.575829, 0.01},
+	}
+	for _, test := range tests {
+		if got := normalPValue(test.z); !closeTo(got, test.want, 1e-6) {
+			t.Errorf("p-value of z = %v: got %v, want %v", test.z, got, test.want)
+		}
+	}
+}
+
+func TestPercentileRank(t *testing.T) {
+	values := []float64{1, 2, 2, 3, 4}
+	tests := []struct {
+		value, want float64
+	}{
+		{0, 0},
+		{1, 10},
+		{2, 40},
+		{2.5, 60},
+		{4, 90},
+		{5, 100},
+	}
+	for _, test := range tests {
+		if got := PercentileRank(values, test.value); got != test.want {
+			t.Errorf("rank of %v: got %v, want %v", test.value, got, test.want)
+		}
+	}
+	if got := PercentileRank(nil, 3); got != 0 {
+		t.Errorf("rank among no values: got %v, want 0", got)
+	}
+	if got := PercentileRank([]float64{3}, 3); got != 50 {
+		t.Errorf("rank among only itself: got %v, want 50", got)
+	}
+}
@@ -0,0 +1,45 @@
+package schema
+
+import "time"
+
+// ObjectInfo describes an object stored in a bucket.
+//
+// Fields:
+//
+//	Bucket:       The bucket the object is stored in.
+//	Name:         The object's name, which may contain slashes to group objects under prefixes.
+//	Size:         The object's size in bytes.
+//	Content_type: The object's MIME type, as uploaded or detected from its content.
+//	Etag:         The MD5 hash of the object's content, in hex.
+//	Updated_at:   When the object was last uploaded.
+//	Metadata:     The custom metadata uploaded with the object.
+type ObjectInfo struct {
+	Bucket       string            `bson:"bucket" json:"bucket"`
+	Name         string            `bson:"name" json:"name"`
+	Size         int64             `bson:"size" json:"size"`
+	Content_type string            `bson:"content_type" json:"content_type"`
+	Etag         string            `bson:"etag" json:"etag"`
+	Updated_at   time.Time         `bson:"updated_at" json:"updated_at"`
+	Metadata     map[string]string `bson:"metadata" json:"metadata"`
+}
+
+// BucketInfo describes a bucket and lists the objects in it.
+//
+// Fields:
+//
+//	Name:         The bucket's name.
+//	Object_count: How many objects are in the bucket.
+//	Total_size:   The total size of every object in the bucket, in bytes.
+//	Updated_at:   When an object in the bucket was last uploaded.
+//	Prefix:       The prefix the listed objects were filtered by.
+//	Objects:      The objects whose names start with the prefix. When listing with a delimiter, only those with no delimiter after the prefix.
+//	Prefixes:     When listing with a delimiter, the distinct names up to and including the first delimiter after the prefix.
+type BucketInfo struct {
+	Name         string       `bson:"name" json:"name"`
+	Object_count int          `bson:"object_count" json:"object_count"`
+	Total_size   int64        `bson:"total_size" json:"total_size"`
+	Updated_at   time.Time    `bson:"updated_at" json:"updated_at"`
+	Prefix       string       `bson:"prefix" json:"prefix"`
+	Objects      []ObjectInfo `bson:"objects" json:"objects"`
+	Prefixes     []string     `bson:"prefixes" json:"prefixes"`
+}
@@ -0,0 +1,108 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Season represents the part of the academic year an academic session takes place in, using the same letter codes as academic session names.
+type Season string
+
+const (
+	SPRING Season = "S"
+	SUMMER Season = "U"
+	FALL   Season = "F"
+)
+
+// seasonOrder gives the position of each season within a calendar year.
+var seasonOrder = map[Season]int{SPRING: 0, SUMMER: 1, FALL: 2}
+
+// Term represents a single academic session such as "23F", split into its calendar year and season so terms can be ordered and compared.
+type Term struct {
+	Year   int    `bson:"year" json:"year"`
+	Season Season `bson:"season" json:"season"`
+}
+
+// ParseTerm parses an academic session name of the form "<2-digit year><season>" (for example "22F", "23S" or "23U") into a Term.
+func ParseTerm(name string) (Term, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if len(name) != 3 {
+		return Term{}, fmt.Errorf("invalid academic session name %q", name)
+	}
+
+	// strconv.Atoi alone would accept a signed year such as "-1"
+	if name[0] < '0' || name[0] > '9' || name[1] < '0' || name[1] > '9' {
+		return Term{}, fmt.Errorf("invalid academic session year %q", name)
+	}
+	year, _ := strconv.Atoi(name[:2])
+
+	season := Season(name[2:])
+	if _, ok := seasonOrder[season]; !ok {
+		return Term{}, fmt.Errorf("invalid academic session season %q", name)
+	}
+
+	return Term{Year: 2000 + year, Season: season}, nil
+}
+
+// TermForDate returns the term that is in session (or about to begin) on the given date.
+func TermForDate(date time.Time) Term {
+	switch {
+	case date.Month() <= time.May:
+		return Term{Year: date.Year(), Season: SPRING}
+	case date.Month() <= time.July:
+		return Term{Year: date.Year(), Season: SUMMER}
+	default:
+		return Term{Year: date.Year(), Season: FALL}
+	}
+}
+
+// String returns the academic session name for the term, e.g. "23F".
+func (t Term) String() string {
+	return fmt.Sprintf("%02d%s", t.Year%100, t.Season)
+}
+
+// Index returns a monotonically increasing number for the term, so that comparing two indices orders the terms chronologically.
+func (t Term) Index() int {
+	return t.Year*3 + seasonOrder[t.Season]
+}
+
+// Before reports whether t takes place before other.
+func (t Term) Before(other Term) bool {
+	return t.Index() < other.Index()
+}
+
+// Next returns the term immediately following t.
+func (t Term) Next() Term {
+	switch t.Season {
+	case SPRING:
+		return Term{Year: t.Year, Season: SUMMER}
+	case SUMMER:
+		return Term{Year: t.Year, Season: FALL}
+	default:
+		return Term{Year: t.Year + 1, Season: SPRING}
+	}
+}
+
+// TermRange returns every term from start to end, inclusive, in chronological order.
+func TermRange(start, end Term) []Term {
+	var terms []Term
+	for term := start; !end.Before(term); term = term.Next() {
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// SortTermNames sorts academic session names chronologically. Names that can't be parsed are placed last, in their original order.
+func SortTermNames(names []string) {
+	sort.SliceStable(names, func(i, j int) bool {
+		a, errA := ParseTerm(names[i])
+		b, errB := ParseTerm(names[j])
+		if errA != nil || errB != nil {
+			return errA == nil && errB != nil
+		}
+		return a.Before(b)
+	})
+}
@@ -0,0 +1,43 @@
+package schema
+
+import "testing"
+
+func TestParseTerm(t *testing.T) {
+	valid := map[string]Term{
+		"23F":  {Year: 2023, Season: FALL},
+		"00S":  {Year: 2000, Season: SPRING},
+		"19u":  {Year: 2019, Season: SUMMER},
+		" 22S": {Year: 2022, Season: SPRING},
+	}
+	for name, want := range valid {
+		got, err := ParseTerm(name)
+		if err != nil {
+			t.Errorf("%q: %v", name, err)
+		} else if got != want {
+			t.Errorf("%q: got %+v, want %+v", name, got, want)
+		}
+	}
+
+	for _, name := range []string{"", "23", "2023F", "-1F", "+1F", " 1F", "1 F", "AAF", "23X", "23FF"} {
+		if term, err := ParseTerm(name); err == nil {
+			t.Errorf("%q: got %+v, want an error", name, term)
+		}
+	}
+}
+
+func TestTermRange(t *testing.T) {
+	terms := TermRange(Term{Year: 2022, Season: FALL}, Term{Year: 2023, Season: FALL})
+	want := []string{"22F", "23S", "23U", "23F"}
+	if len(terms) != len(want) {
+		t.Fatalf("got %v, want %v", terms, want)
+	}
+	for i, term := range terms {
+		if term.String() != want[i] {
+			t.Errorf("term %d: got %s, want %s", i, term, want[i])
+		}
+	}
+
+	if terms := TermRange(Term{Year: 2023, Season: FALL}, Term{Year: 2023, Season: SPRING}); len(terms) != 0 {
+		t.Errorf("got %v for a range ending before it starts, want none", terms)
+	}
+}
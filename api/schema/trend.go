@@ -0,0 +1,171 @@
+package schema
+
+import (
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// A fitted trend is significant when the p-value of its slope is below this level.
+const TREND_SIGNIFICANCE_LEVEL = 0.05
+
+// A term is an outlier when either of its statistics is further than this many standard errors from the fitted trend.
+const TREND_OUTLIER_Z_SCORE = 2.0
+
+// The fewest terms needed before any term is flagged as an outlier, since a line through three points barely leaves residuals to compare.
+const TREND_MIN_OUTLIER_TERMS = 4
+
+// TrendTerm holds the statistics of a single academic session within a grade trend.
+type TrendTerm struct {
+	Academic_session string               `bson:"academic_session" json:"academic_session"`
+	Mean_gpa         float64              `bson:"mean_gpa" json:"mean_gpa"`
+	Dfw_rate         float64              `bson:"dfw_rate" json:"dfw_rate"`
+	Total_students   int                  `bson:"total_students" json:"total_students"`
+	Sample_count     int                  `bson:"sample_count" json:"sample_count"`
+	Professors       []primitive.ObjectID `bson:"professors" json:"professors"`
+	// Whether the set of professors differs from the previous term's
+	Professor_change bool `bson:"professor_change" json:"professor_change"`
+	// How many standard errors each statistic lies from its fitted trend
+	Gpa_z_score float64 `bson:"gpa_z_score" json:"gpa_z_score"`
+	Dfw_z_score float64 `bson:"dfw_z_score" json:"dfw_z_score"`
+	Outlier     bool    `bson:"outlier" json:"outlier"`
+}
+
+// TrendFit is a least-squares line fitted to a statistic over time. The slope is the change in the statistic per term, counting spring, summer
+// and fall as one term each, and the intercept is the fitted value at the first term.
+type TrendFit struct {
+	Slope       float64 `bson:"slope" json:"slope"`
+	Intercept   float64 `bson:"intercept" json:"intercept"`
+	R_squared   float64 `bson:"r_squared" json:"r_squared"`
+	P_value     float64 `bson:"p_value" json:"p_value"`
+	Significant bool    `bson:"significant" json:"significant"`
+}
+
+// GradeTrend is the per-term mean GPA and DFW rate of a set of sections, along with a trend fitted to each. The fits are nil when there are
+// fewer than three terms to fit.
+type GradeTrend struct {
+	Terms    []TrendTerm `bson:"terms" json:"terms"`
+	Mean_gpa *TrendFit   `bson:"mean_gpa" json:"mean_gpa"`
+	Dfw_rate *TrendFit   `bson:"dfw_rate" json:"dfw_rate"`
+}
+
+// NewGradeTrend fits trends to the given terms, which must be in chronological order, and flags the terms that stray from them.
+func NewGradeTrend(terms []TrendTerm) GradeTrend {
+	trend := GradeTrend{Terms: terms}
+
+	x := make([]float64, len(terms))
+	gpa := make([]float64, len(terms))
+	dfw := make([]float64, len(terms))
+	var first Term
+	for i, term := range terms {
+		parsed, _ := ParseTerm(term.Academic_session)
+		if i == 0 {
+			first = parsed
+		}
+		x[i] = float64(parsed.Index() - first.Index())
+		gpa[i] = term.Mean_gpa
+		dfw[i] = term.Dfw_rate
+
+		if i > 0 {
+			terms[i].Professor_change = !sameProfessors(term.Professors, terms[i-1].Professors)
+		}
+	}
+
+	var gpaScores, dfwScores []float64
+	trend.Mean_gpa, gpaScores = fitTrend(x, gpa)
+	trend.Dfw_rate, dfwScores = fitTrend(x, dfw)
+	if trend.Mean_gpa == nil {
+		return trend
+	}
+
+	for i := range terms {
+		terms[i].Gpa_z_score = roundStat(gpaScores[i])
+		terms[i].Dfw_z_score = roundStat(dfwScores[i])
+		terms[i].Outlier = len(terms) >= TREND_MIN_OUTLIER_TERMS &&
+			(math.Abs(gpaScores[i]) > TREND_OUTLIER_Z_SCORE || math.Abs(dfwScores[i]) > TREND_OUTLIER_Z_SCORE)
+	}
+	return trend
+}
+
+// fitTrend fits a least-squares line to the points, returning the fit and the standardized residual of each point. It returns nil when there are
+// fewer than three points or they all share one x value.
+func fitTrend(x []float64, y []float64) (*TrendFit, []float64) {
+	n := float64(len(x))
+	if len(x) < 3 {
+		return nil, nil
+	}
+
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var sxx, sxy, syy float64
+	for i := range x {
+		sxx += (x[i] - meanX) * (x[i] - meanX)
+		sxy += (x[i] - meanX) * (y[i] - meanY)
+		syy += (y[i] - meanY) * (y[i] - meanY)
+	}
+	if sxx == 0 {
+		return nil, nil
+	}
+
+	slope := sxy / sxx
+	intercept := meanY - slope*meanX
+
+	residuals := make([]float64, len(x))
+	var sse, scale float64
+	for i := range x {
+		residuals[i] = y[i] - (intercept + slope*x[i])
+		sse += residuals[i] * residuals[i]
+		scale += y[i] * y[i]
+	}
+	// Points on a line leave only rounding error, which mustn't be standardized into residuals
+	if sse <= 1e-12*scale {
+		sse = 0
+	}
+
+	fit := &TrendFit{Slope: slope, Intercept: intercept, R_squared: 1, P_value: 0}
+	if syy > 0 {
+		fit.R_squared = 1 - sse/syy
+	}
+
+	// A perfect fit leaves no error to standardize by, so every residual counts as zero
+	standardError := math.Sqrt(sse / (n - 2))
+	scores := make([]float64, len(x))
+	if standardError > 0 {
+		for i := range residuals {
+			scores[i] = residuals[i] / standardError
+		}
+		t := slope / (standardError / math.Sqrt(sxx))
+		fit.P_value = studentTPValue(t, n-2)
+	} else if slope == 0 {
+		fit.P_value = 1
+	}
+	fit.Significant = fit.P_value < TREND_SIGNIFICANCE_LEVEL
+
+	fit.Slope = roundPrecise(fit.Slope)
+	fit.Intercept = roundStat(fit.Intercept)
+	fit.R_squared = roundPrecise(fit.R_squared)
+	fit.P_value = roundPrecise(fit.P_value)
+	return fit, scores
+}
+
+// sameProfessors reports whether two lists of professor ids contain the same professors.
+func sameProfessors(a []primitive.ObjectID, b []primitive.ObjectID) bool {
+	seen := make(map[primitive.ObjectID]bool, len(a))
+	for _, id := range a {
+		seen[id] = true
+	}
+	matched := make(map[primitive.ObjectID]bool, len(b))
+	for _, id := range b {
+		if !seen[id] {
+			return false
+		}
+		matched[id] = true
+	}
+	return len(matched) == len(seen)
+}
@@ -0,0 +1,143 @@
+package schema
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// trendTerms builds consecutive terms with the given mean GPAs and DFW rates, starting at the given academic session.
+func trendTerms(start string, gpas []float64, dfwRates []float64) []TrendTerm {
+	term, _ := ParseTerm(start)
+	terms := make([]TrendTerm, len(gpas))
+	for i := range gpas {
+		terms[i] = TrendTerm{Academic_session: term.String(), Mean_gpa: gpas[i], Dfw_rate: dfwRates[i]}
+		term = term.Next()
+	}
+	return terms
+}
+
+func TestFitTrend(t *testing.T) {
+	fit, scores := fitTrend([]float64{0, 1, 2, 3, 4}, []float64{3.1, 3.0, 3.2, 2.8, 2.9})
+	if fit == nil {
+		t.Fatal("got no fit")
+	}
+	want := TrendFit{Slope: -0.06, Intercept: 3.12, R_squared: 0.36, P_value: 0.2848, Significant: false}
+	if *fit != want {
+		t.Errorf("got %+v, want %+v", *fit, want)
+	}
+	wantScores := []float64{-0.1369, -0.4108, 1.3693, -0.9585, 0.1369}
+	for i := range wantScores {
+		if !closeTo(scores[i], wantScores[i], 1e-4) {
+			t.Errorf("point %d: got a standardized residual of %v, want %v", i, scores[i], wantScores[i])
+		}
+	}
+}
+
+func TestFitTrendEdgeCases(t *testing.T) {
+	if fit, scores := fitTrend([]float64{0, 1}, []float64{3, 3.5}); fit != nil || scores != nil {
+		t.Errorf("two points: got %+v, want no fit", fit)
+	}
+	if fit, _ := fitTrend([]float64{2, 2, 2}, []float64{3, 3.5, 2.5}); fit != nil {
+		t.Errorf("one x value: got %+v, want no fit", fit)
+	}
+
+	// A perfect line leaves no error, so its slope is certain and no point strays from it
+	fit, scores := fitTrend([]float64{0, 1, 2, 3, 4}, []float64{2.1, 2.3, 2.5, 2.7, 2.9})
+	if fit == nil || fit.Slope != 0.2 || fit.Intercept != 2.1 || fit.R_squared != 1 || fit.P_value != 0 || !fit.Significant {
+		t.Errorf("perfect line: got %+v, want a significant slope of 0.2 from 2.1", fit)
+	}
+	for i, score := range scores {
+		if score != 0 {
+			t.Errorf("perfect line, point %d: got a standardized residual of %v, want 0", i, score)
+		}
+	}
+
+	// A flat line has no slope to be significant
+	fit, _ = fitTrend([]float64{0, 1, 2}, []float64{3, 3, 3})
+	if fit == nil || fit.Slope != 0 || fit.P_value != 1 || fit.Significant {
+		t.Errorf("flat line: got %+v, want an insignificant slope of 0", fit)
+	}
+}
+
+func TestNewGradeTrend(t *testing.T) {
+	terms := trendTerms("20S",
+		[]float64{3.0, 3.02, 3.04, 3.06, 3.7, 3.10, 3.12, 3.14},
+		[]float64{30, 28, 27, 25, 9, 21, 20, 18},
+	)
+	trend := NewGradeTrend(terms)
+	if trend.Mean_gpa == nil || trend.Dfw_rate == nil {
+		t.Fatal("got no fits")
+	}
+	if trend.Mean_gpa.Slope != 0.0274 || trend.Mean_gpa.P_value != 0.4805 {
+		t.Errorf("got mean GPA fit %+v, want a slope of 0.0274 with a p-value of 0.4805", *trend.Mean_gpa)
+	}
+	if trend.Dfw_rate.Slope != -1.881 || trend.Dfw_rate.P_value != 0.0647 {
+		t.Errorf("got DFW rate fit %+v, want a slope of -1.881 with a p-value of 0.0647", *trend.Dfw_rate)
+	}
+
+	for i, term := range trend.Terms {
+		if term.Outlier != (i == 4) {
+			t.Errorf("term %s: got outlier %t, want %t", term.Academic_session, term.Outlier, i == 4)
+		}
+	}
+	if trend.Terms[4].Gpa_z_score != 2.28 || trend.Terms[4].Dfw_z_score != -2.28 {
+		t.Errorf("got z-scores %v and %v for the outlier, want 2.28 and -2.28", trend.Terms[4].Gpa_z_score, trend.Terms[4].Dfw_z_score)
+	}
+}
+
+func TestNewGradeTrendSkipsMissingTerms(t *testing.T) {
+	// Fall 2021 is missing, so the terms are 0, 1, 3 and 4 terms after the first
+	terms := []TrendTerm{
+		{Academic_session: "21S", Mean_gpa: 2.0},
+		{Academic_session: "21U", Mean_gpa: 2.5},
+		{Academic_session: "22S", Mean_gpa: 3.4},
+		{Academic_session: "22U", Mean_gpa: 4.1},
+	}
+	trend := NewGradeTrend(terms)
+	if trend.Mean_gpa == nil || trend.Mean_gpa.Slope != 0.51 || trend.Mean_gpa.Intercept != 1.98 || trend.Mean_gpa.P_value != 0.0036 {
+		t.Errorf("got mean GPA fit %+v, want a slope of 0.51 from 1.98 with a p-value of 0.0036", trend.Mean_gpa)
+	}
+}
+
+func TestNewGradeTrendTooFewTerms(t *testing.T) {
+	for _, count := range []int{0, 1, 2} {
+		terms := trendTerms("23F", make([]float64, count), make([]float64, count))
+		trend := NewGradeTrend(terms)
+		if trend.Mean_gpa != nil || trend.Dfw_rate != nil {
+			t.Errorf("%d terms: got fits %+v and %+v, want none", count, trend.Mean_gpa, trend.Dfw_rate)
+		}
+		for _, term := range trend.Terms {
+			if term.Outlier {
+				t.Errorf("%d terms: got %s flagged as an outlier", count, term.Academic_session)
+			}
+		}
+	}
+
+	// Three terms are fitted, but too few to flag outliers
+	trend := NewGradeTrend(trendTerms("23S", []float64{2.0, 3.9, 2.1}, []float64{10, 50, 11}))
+	if trend.Mean_gpa == nil {
+		t.Fatal("three terms: got no fit")
+	}
+	for _, term := range trend.Terms {
+		if term.Outlier {
+			t.Errorf("three terms: got %s flagged as an outlier", term.Academic_session)
+		}
+	}
+}
+
+func TestNewGradeTrendProfessorChange(t *testing.T) {
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+	terms := trendTerms("22F", []float64{3, 3, 3, 3}, []float64{10, 10, 10, 10})
+	terms[0].Professors = []primitive.ObjectID{a}
+	terms[1].Professors = []primitive.ObjectID{a}
+	terms[2].Professors = []primitive.ObjectID{a, b}
+	terms[3].Professors = []primitive.ObjectID{b, a, b}
+
+	want := []bool{false, false, true, false}
+	for i, term := range NewGradeTrend(terms).Terms {
+		if term.Professor_change != want[i] {
+			t.Errorf("term %s: got professor change %t, want %t", term.Academic_session, term.Professor_change, want[i])
+		}
+	}
+}
@@ -2,8 +2,10 @@
 package main
 
 import (
+	"github.com/UTDNebula/nebula-api/api/common/evaluations"
 	"github.com/UTDNebula/nebula-api/api/common/log"
 	"github.com/UTDNebula/nebula-api/api/configs"
+	"github.com/UTDNebula/nebula-api/api/controllers"
 	_ "github.com/UTDNebula/nebula-api/api/docs"
 	"github.com/UTDNebula/nebula-api/api/routes"
 	"github.com/gin-gonic/gin"
@@ -30,6 +32,7 @@ import (
 // Middleware functions include:
 //   - CORS: Enables Cross-Origin Resource Sharing.
 //   - LogRequest: Logs incoming requests for monitoring purposes.
+//   - Authenticate: Checks the API key of every request when a key store is configured.
 //
 // Swagger documentation is hosted at the "/swagger/*any" endpoint.
 //
@@ -42,6 +45,15 @@ func main() {
 	// Establish the connection to the database
 	configs.ConnectDB()
 
+	// Keep the precomputed grade aggregates up to date
+	controllers.StartGradeAggregates()
+
+	// Build the typeahead search index and autocomplete DAG
+	controllers.StartAutocomplete()
+
+	// Run the queued evaluation scrape jobs
+	evaluations.StartWorkers()
+
 	// Configure Gin Router
 	router := gin.New()
 
@@ -51,16 +63,24 @@ func main() {
 	// Enable Logging
 	router.Use(LogRequest)
 
+	// Check API keys
+	router.Use(controllers.Authenticate)
+
 	// Setup swagger-ui hosted
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Connect Routes
 	routes.CourseRoute(router)
 	routes.SectionRoute(router)
+	routes.EvaluationRoute(router)
 	routes.ProfessorRoute(router)
+	routes.OrganizationRoute(router)
+	routes.EventRoute(router)
 	routes.GradesRoute(router)
 	routes.AutocompleteRoute(router)
 	routes.StorageRoute(router)
+	routes.CoreRoute(router)
+	routes.AdminRoute(router)
 
 	// Retrieve the port string to serve traffic on
 	portString := configs.GetPortString()
@@ -76,8 +96,9 @@ func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Accept, x-api-key")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "OPTIONS, GET")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag, Location, Content-Length, Content-Range, Accept-Ranges")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "OPTIONS, GET, POST")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, If-None-Match, Range, x-api-key")
 
 		if c.Request.Method == "OPTIONS" {
 			c.IndentedJSON(204, "")